@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+type report struct {
+	name string
+	// coverage is the mean share of a cluster's articles mentioning a keyword
+	coverage float64
+	// specificity is one minus the mean share of all clusters mentioning a
+	// keyword; generic words score low
+	specificity float64
+	// overlap is the mean Jaccard similarity with the frequency baseline
+	overlap   float64
+	precision float64
+	recall    float64
+}
+
+func evaluate(name string, extracted, baseline [][]string, clusters []labelledCluster, texts [][]string) report {
+	r := report{name: name}
+
+	var coverageSum, specificitySum float64
+	var keywords int
+	var overlapSum float64
+	var tp, predicted, relevant int
+
+	for i, kws := range extracted {
+		for _, kw := range kws {
+			coverageSum += mentionShare(kw, texts[i])
+
+			mentioned := 0
+			for _, t := range texts {
+				if mentionShare(kw, t) > 0 {
+					mentioned++
+				}
+			}
+			specificitySum += 1 - float64(mentioned)/float64(len(texts))
+			keywords++
+		}
+
+		overlapSum += jaccard(kws, baseline[i])
+
+		if len(clusters[i].Keywords) > 0 {
+			refs := make(map[string]bool, len(clusters[i].Keywords))
+			for _, ref := range clusters[i].Keywords {
+				refs[strings.ToLower(ref)] = true
+			}
+			for _, kw := range kws {
+				if refs[kw] {
+					tp++
+				}
+			}
+			predicted += len(kws)
+			relevant += len(refs)
+		}
+	}
+
+	if keywords > 0 {
+		r.coverage = coverageSum / float64(keywords)
+		r.specificity = specificitySum / float64(keywords)
+	}
+	r.overlap = overlapSum / float64(len(extracted))
+	if predicted > 0 {
+		r.precision = float64(tp) / float64(predicted)
+	}
+	if relevant > 0 {
+		r.recall = float64(tp) / float64(relevant)
+	}
+	return r
+}
+
+func mentionShare(keyword string, texts []string) float64 {
+	if len(texts) == 0 {
+		return 0.0
+	}
+	n := 0
+	for _, t := range texts {
+		if strings.Contains(t, keyword) {
+			n++
+		}
+	}
+	return float64(n) / float64(len(texts))
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	set := make(map[string]bool, len(a))
+	for _, x := range a {
+		set[x] = true
+	}
+	union := len(set)
+	shared := 0
+	for _, x := range b {
+		if set[x] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+func printReports(reports []report, withReferences bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "extractor\tcoverage\tspecificity\toverlap"
+	if withReferences {
+		header += "\tprecision\trecall"
+	}
+	fmt.Fprintln(w, header)
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%.3f", r.name, r.coverage, r.specificity, r.overlap)
+		if withReferences {
+			fmt.Fprintf(w, "\t%.3f\t%.3f", r.precision, r.recall)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
@@ -0,0 +1,160 @@
+// Command kweval compares keyword extractors on the same article clusters.
+//
+// The dataset is JSONL with one cluster per line. Reference keywords are
+// optional; without them only the unsupervised metrics are reported.
+//
+//	{"articles": [{"title": "...", "description": "...", "language": "de"}, ...],
+//	 "keywords": ["Friedrich Merz", "Haushalt"]}
+//
+// Usage:
+//
+//	go run ./cmd/kweval -data clusters.jsonl [-extractors frequency,textrank,rake] [-top 8] [-show 3]
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"news-swipe/backend/graph/model"
+	"news-swipe/backend/utils"
+)
+
+type labelledArticle struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Language    string `json:"language"`
+}
+
+type labelledCluster struct {
+	Articles []labelledArticle `json:"articles"`
+	Keywords []string          `json:"keywords"`
+}
+
+func main() {
+	dataPath := flag.String("data", "", "path to the JSONL cluster dataset")
+	names := flag.String("extractors", strings.Join(utils.KeywordExtractorNames, ","), "comma separated extractors to compare")
+	top := flag.Int("top", 8, "keywords extracted per cluster")
+	show := flag.Int("show", 0, "print the keywords of the first n clusters")
+	flag.Parse()
+
+	if *dataPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	clusters, err := loadClusters(*dataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(clusters) == 0 {
+		log.Fatal("dataset contains no clusters")
+	}
+
+	var extractors []utils.KeywordExtractor
+	for _, name := range strings.Split(*names, ",") {
+		extractor, err := utils.NewKeywordExtractor(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		extractors = append(extractors, extractor)
+	}
+
+	inputs := make([][]model.Article, len(clusters))
+	texts := make([][]string, len(clusters))
+	for i, c := range clusters {
+		inputs[i], texts[i] = c.toArticles()
+	}
+
+	// The frequency scorer is the baseline the others are compared against
+	baseline, _ := utils.NewKeywordExtractor("frequency")
+	baselineResults := extractAll(baseline, inputs, *top)
+
+	results := make([]report, 0, len(extractors))
+	for _, extractor := range extractors {
+		extracted := extractAll(extractor, inputs, *top)
+		results = append(results, evaluate(extractor.Name(), extracted, baselineResults, clusters, texts))
+
+		if *show > 0 {
+			fmt.Printf("%s:\n", extractor.Name())
+			for i := 0; i < *show && i < len(extracted); i++ {
+				fmt.Printf("  %d: %s\n", i+1, strings.Join(extracted[i], ", "))
+			}
+			fmt.Println()
+		}
+	}
+
+	fmt.Printf("clusters: %d, keywords per cluster: %d\n\n", len(clusters), *top)
+	printReports(results, hasReferences(clusters))
+}
+
+func loadClusters(path string) ([]labelledCluster, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	var clusters []labelledCluster
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var cluster labelledCluster
+		if err := json.Unmarshal(scanner.Bytes(), &cluster); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	return clusters, nil
+}
+
+// toArticles converts a cluster to articles and the lowercase texts used to
+// check which articles mention a keyword.
+func (c labelledCluster) toArticles() ([]model.Article, []string) {
+	articles := make([]model.Article, len(c.Articles))
+	texts := make([]string, len(c.Articles))
+	for i, a := range c.Articles {
+		var lang model.Language
+		if err := lang.Scan(a.Language); err != nil {
+			lang = model.FromLingua(utils.DetectArticleLanguage(a.Title, a.Description))
+		}
+		articles[i] = model.Article{
+			Title:       a.Title,
+			Description: a.Description,
+			Language:    lang,
+		}
+		texts[i] = strings.ToLower(a.Title + " " + a.Description)
+	}
+	return articles, texts
+}
+
+func extractAll(extractor utils.KeywordExtractor, clusters [][]model.Article, top int) [][]string {
+	results := make([][]string, len(clusters))
+	for i, cluster := range clusters {
+		for _, kw := range extractor.Extract(cluster, top) {
+			results[i] = append(results[i], strings.ToLower(kw.Keyword))
+		}
+	}
+	return results
+}
+
+func hasReferences(clusters []labelledCluster) bool {
+	for _, c := range clusters {
+		if len(c.Keywords) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"news-swipe/backend/utils"
+)
+
+type sample struct {
+	scores utils.SimilarityScores
+	same   bool
+}
+
+type metrics struct {
+	threshold float64
+	tp        int
+	fp        int
+	fn        int
+	precision float64
+	recall    float64
+	f1        float64
+}
+
+type gridResult struct {
+	config  utils.SimilarityConfig
+	metrics metrics
+}
+
+// scorePairs computes the component scores once per pair so that thresholds
+// and weights can be varied without re-running the string comparisons.
+func scorePairs(pairs []labelledPair, config utils.SimilarityConfig) []sample {
+	samples := make([]sample, len(pairs))
+	for i, p := range pairs {
+		samples[i] = sample{
+			scores: utils.ArticleSimilarityScores(p.A.toArticle(), p.B.toArticle(), config),
+			same:   p.Same,
+		}
+	}
+	return samples
+}
+
+func evaluate(samples []sample, config utils.SimilarityConfig, threshold float64) metrics {
+	m := metrics{threshold: threshold}
+	for _, s := range samples {
+		predicted := s.scores.Weighted(config) >= threshold
+		switch {
+		case predicted && s.same:
+			m.tp++
+		case predicted && !s.same:
+			m.fp++
+		case !predicted && s.same:
+			m.fn++
+		}
+	}
+
+	if m.tp+m.fp > 0 {
+		m.precision = float64(m.tp) / float64(m.tp+m.fp)
+	}
+	if m.tp+m.fn > 0 {
+		m.recall = float64(m.tp) / float64(m.tp+m.fn)
+	}
+	if m.precision+m.recall > 0 {
+		m.f1 = 2 * m.precision * m.recall / (m.precision + m.recall)
+	}
+	return m
+}
+
+func prCurve(samples []sample, config utils.SimilarityConfig, steps int) []metrics {
+	if steps < 1 {
+		steps = 1
+	}
+	points := make([]metrics, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		points = append(points, evaluate(samples, config, float64(i)/float64(steps)))
+	}
+	return points
+}
+
+func bestThreshold(samples []sample, config utils.SimilarityConfig, steps int) metrics {
+	var best metrics
+	for _, m := range prCurve(samples, config, steps) {
+		if m.f1 > best.f1 {
+			best = m
+		}
+	}
+	return best
+}
+
+// gridSearch tries every combination of component weights that sums to one in
+// increments of step and returns the configurations ordered by their best F1.
+func gridSearch(samples []sample, base utils.SimilarityConfig, step float64, steps int) []gridResult {
+	if step <= 0 || step > 1 {
+		step = 0.05
+	}
+	n := int(math.Round(1 / step))
+
+	var results []gridResult
+	for t := 0; t <= n; t++ {
+		for d := 0; t+d <= n; d++ {
+			for tm := 0; t+d+tm <= n; tm++ {
+				s := n - t - d - tm
+
+				config := base
+				config.TitleWeight = float64(t) / float64(n)
+				config.DescWeight = float64(d) / float64(n)
+				config.TimeWeight = float64(tm) / float64(n)
+				config.SourceWeight = float64(s) / float64(n)
+
+				results = append(results, gridResult{
+					config:  config,
+					metrics: bestThreshold(samples, config, steps),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].metrics.f1 != results[j].metrics.f1 {
+			return results[i].metrics.f1 > results[j].metrics.f1
+		}
+		return results[i].metrics.precision > results[j].metrics.precision
+	})
+	return results
+}
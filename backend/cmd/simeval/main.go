@@ -0,0 +1,174 @@
+// Command simeval measures how well a SimilarityConfig separates labelled
+// same-story and different-story article pairs.
+//
+// The dataset is JSONL with one pair per line:
+//
+//	{"a": {"title": "...", "description": "...", "source": "FAZ", "publishedAt": "2025-05-07T17:46:21+02:00", "language": "de"},
+//	 "b": {...}, "same": true}
+//
+// Usage:
+//
+//	go run ./cmd/simeval -data pairs.jsonl [-config config.json] [-threshold 0.3] [-curve] [-grid]
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"news-swipe/backend/graph/model"
+	"news-swipe/backend/utils"
+)
+
+type labelledArticle struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Source      model.Source `json:"source"`
+	PublishedAt time.Time    `json:"publishedAt"`
+	Language    string       `json:"language"`
+}
+
+type labelledPair struct {
+	A    labelledArticle `json:"a"`
+	B    labelledArticle `json:"b"`
+	Same bool            `json:"same"`
+}
+
+func main() {
+	dataPath := flag.String("data", "", "path to the labelled JSONL dataset")
+	configPath := flag.String("config", "", "optional JSON file with a SimilarityConfig (defaults to DefaultSimilarityConfig)")
+	threshold := flag.Float64("threshold", utils.LinkThreshold, "threshold to report precision, recall and F1 at")
+	curve := flag.Bool("curve", false, "print the precision/recall curve")
+	steps := flag.Int("steps", 20, "number of thresholds on the precision/recall curve")
+	grid := flag.Bool("grid", false, "grid-search the component weights")
+	gridStep := flag.Float64("grid-step", 0.05, "weight increment used by the grid search")
+	top := flag.Int("top", 5, "number of grid search results to print")
+	flag.Parse()
+
+	if *dataPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config := utils.DefaultSimilarityConfig()
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			log.Fatalf("failed to read config: %v", err)
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			log.Fatalf("failed to parse config: %v", err)
+		}
+	}
+
+	pairs, err := loadPairs(*dataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pairs) == 0 {
+		log.Fatal("dataset contains no pairs")
+	}
+
+	samples := scorePairs(pairs, config)
+	positives := 0
+	for _, s := range samples {
+		if s.same {
+			positives++
+		}
+	}
+	fmt.Printf("pairs: %d (same story: %d, different story: %d)\n\n", len(samples), positives, len(samples)-positives)
+
+	m := evaluate(samples, config, *threshold)
+	fmt.Printf("threshold %.3f: precision %.3f, recall %.3f, F1 %.3f (tp %d, fp %d, fn %d)\n",
+		*threshold, m.precision, m.recall, m.f1, m.tp, m.fp, m.fn)
+
+	best := bestThreshold(samples, config, *steps)
+	fmt.Printf("best threshold %.3f: precision %.3f, recall %.3f, F1 %.3f\n",
+		best.threshold, best.precision, best.recall, best.f1)
+
+	if *curve {
+		fmt.Println()
+		printCurve(prCurve(samples, config, *steps))
+	}
+
+	if *grid {
+		fmt.Println()
+		results := gridSearch(samples, config, *gridStep, *steps)
+		if len(results) > *top {
+			results = results[:*top]
+		}
+		printGrid(results)
+	}
+}
+
+func loadPairs(path string) ([]labelledPair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	var pairs []labelledPair
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var pair labelledPair
+		if err := json.Unmarshal(scanner.Bytes(), &pair); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		pairs = append(pairs, pair)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	return pairs, nil
+}
+
+func (l labelledArticle) toArticle() model.Article {
+	var lang model.Language
+	if err := lang.Scan(l.Language); err != nil {
+		lang = model.FromLingua(utils.DetectArticleLanguage(l.Title, l.Description))
+	}
+	return model.Article{
+		Title:       l.Title,
+		Description: l.Description,
+		Source:      l.Source,
+		PublishedAt: l.PublishedAt,
+		Language:    lang,
+	}
+}
+
+func printCurve(points []metrics) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "threshold\tprecision\trecall\tF1")
+	for _, p := range points {
+		fmt.Fprintf(w, "%.3f\t%.3f\t%.3f\t%.3f\n", p.threshold, p.precision, p.recall, p.f1)
+	}
+	w.Flush()
+}
+
+func printGrid(results []gridResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "title\tdesc\ttime\tsource\tthreshold\tprecision\trecall\tF1")
+	for _, r := range results {
+		fmt.Fprintf(w, "%.2f\t%.2f\t%.2f\t%.2f\t%.3f\t%.3f\t%.3f\t%.3f\n",
+			r.config.TitleWeight, r.config.DescWeight, r.config.TimeWeight, r.config.SourceWeight,
+			r.metrics.threshold, r.metrics.precision, r.metrics.recall, r.metrics.f1)
+	}
+	w.Flush()
+
+	if len(results) > 0 {
+		best, _ := json.MarshalIndent(results[0].config, "", "  ")
+		fmt.Printf("\nbest config:\n%s\n", best)
+	}
+}
@@ -8,25 +8,41 @@ import (
 	"gorm.io/gorm"
 )
 
-func CreateCron(ctx context.Context, db *gorm.DB) {
-	err := FilterLinked(db)
-	if err != nil {
+// runPipeline scrapes new articles and updates everything derived from them.
+// Each step logs its own failure so the later steps still run.
+func runPipeline(db *gorm.DB) {
+	if err := FilterLinked(db); err != nil {
 		utils.Log(utils.Database, err)
 	}
+	if err := utils.BuildStories(db); err != nil {
+		utils.Log(utils.Database, "Story building failed", "error", err)
+	}
+	if err := utils.ExtractEntitiesFromArticles(db); err != nil {
+		utils.Log(utils.Database, "Entity extraction failed", "error", err)
+	}
 	if err := utils.GenerateKeywordsFromArticles(db); err != nil {
 		utils.Log(utils.Database, "Keyword generation failed", "error", err)
 	}
+	if err := utils.SuggestKeywordAliases(db); err != nil {
+		utils.Log(utils.Database, "Keyword alias suggestion failed", "error", err)
+	}
+	if err := utils.RecordKeywordTrends(db); err != nil {
+		utils.Log(utils.Database, "Keyword trend recording failed", "error", err)
+	}
+	if err := utils.UpdateTrendingScores(db); err != nil {
+		utils.Log(utils.Database, "Trending score update failed", "error", err)
+	}
+	if err := utils.BuildKeywordGraph(db); err != nil {
+		utils.Log(utils.Database, "Keyword graph building failed", "error", err)
+	}
+}
+
+func CreateCron(ctx context.Context, db *gorm.DB) {
+	runPipeline(db)
+
 	c := cron.New(cron.WithChain(cron.Recover(cron.DefaultLogger)))
 
-	_, err = c.AddFunc("*/15 * * * *", func() {
-		err := FilterLinked(db)
-		if err != nil {
-			utils.Log(utils.Database, err)
-		}
-		if err := utils.GenerateKeywordsFromArticles(db); err != nil {
-			utils.Log(utils.Database, "Keyword generation failed", "error", err)
-		}
-	})
+	_, err := c.AddFunc("*/15 * * * *", func() { runPipeline(db) })
 	if err != nil {
 		utils.Log(utils.Cron, err)
 	}
@@ -1,15 +1,10 @@
 package cron
 
 import (
+	"context"
 	"fmt"
 	"news-swipe/backend/graph/model"
-	"news-swipe/backend/scrapper/faz"
-	"news-swipe/backend/scrapper/handelsblatt"
-	"news-swipe/backend/scrapper/sueddeutsche"
-	"news-swipe/backend/scrapper/tagesschau"
-	"news-swipe/backend/scrapper/taz"
-	"news-swipe/backend/scrapper/welt"
-	"news-swipe/backend/scrapper/zeit"
+	"news-swipe/backend/scrapper"
 	"news-swipe/backend/utils"
 	"sync"
 	"time"
@@ -22,11 +17,7 @@ type scraperResult struct {
 	source   string
 	articles []model.Article
 	err      error
-}
-
-type scraper struct {
-	name string
-	fn   func() ([]model.Article, error)
+	run      model.ScrapeRun
 }
 
 func FilterLinked(db *gorm.DB) error {
@@ -34,7 +25,11 @@ func FilterLinked(db *gorm.DB) error {
 	utils.CronJobRunsTotal.WithLabelValues("filter_linked").Inc()
 
 	// Scrape articles from all sources concurrently
-	articles, errors := scrapeAllSources()
+	articles, errors, runs := scrapeAllSources()
+
+	if err := utils.RecordScrapeRuns(db, runs); err != nil {
+		utils.Log(utils.Database, "Failed to record scrape runs", "error", err)
+	}
 
 	// Log results
 	if len(articles) == 0 && len(errors) > 0 {
@@ -48,7 +43,10 @@ func FilterLinked(db *gorm.DB) error {
 	detectLanguages(articles)
 
 	// Save to database
-	err := saveToDatabase(db, articles)
+	added, err := saveToDatabase(db, articles)
+	if err == nil {
+		utils.PublishArticlesAdded(context.Background(), added)
+	}
 
 	// Record metrics
 	utils.CronJobDuration.WithLabelValues("filter_linked").Observe(time.Since(startTime).Seconds())
@@ -59,24 +57,14 @@ func FilterLinked(db *gorm.DB) error {
 	return err
 }
 
-func scrapeAllSources() ([]model.Article, []error) {
-	scrapers := []scraper{
-		{"Zeit", zeit.Scrape},
-		{"FAZ", faz.Scrape},
-		{"Tagesschau", tagesschau.Scrape},
-		{"Süddeutsche", sueddeutsche.Scrape},
-		{"Welt", welt.Scrape},
-		{"Handelsblatt", handelsblatt.Scrape},
-		{"TAZ", taz.Scrape},
-	}
-
-	results := make(chan scraperResult, len(scrapers))
+func scrapeAllSources() ([]model.Article, []error, []model.ScrapeRun) {
+	results := make(chan scraperResult, len(scrapper.Scrapers))
 	var wg sync.WaitGroup
 
 	// Launch all scrapers concurrently
-	for _, s := range scrapers {
+	for _, s := range scrapper.Scrapers {
 		wg.Add(1)
-		go runScraper(&wg, results, s.name, s.fn)
+		go runScraper(&wg, results, s)
 	}
 
 	// Close results channel when all scrapers complete
@@ -89,34 +77,41 @@ func scrapeAllSources() ([]model.Article, []error) {
 	return collectResults(results)
 }
 
-func runScraper(wg *sync.WaitGroup, results chan<- scraperResult, name string, scrapeFn func() ([]model.Article, error)) {
+func runScraper(wg *sync.WaitGroup, results chan<- scraperResult, s scrapper.Scraper) {
 	defer wg.Done()
 
+	name := s.Name
 	startTime := time.Now()
 	utils.ScraperRequestsTotal.WithLabelValues(name).Inc()
 
-	articles, err := scrapeFn()
+	articles, err := s.Scrape()
 
 	// Record metrics
-	utils.ScraperDuration.WithLabelValues(name).Observe(time.Since(startTime).Seconds())
+	run := model.ScrapeRun{Source: s.Source, StartedAt: startTime, Duration: time.Since(startTime)}
+	utils.ScraperDuration.WithLabelValues(name).Observe(run.Duration.Seconds())
 	if err != nil {
 		utils.ScraperErrorsTotal.WithLabelValues(name).Inc()
+		run.Error = err.Error()
 	} else {
 		utils.ArticlesScraped.WithLabelValues(name).Add(float64(len(articles)))
+		run.Articles = len(articles)
 	}
 
 	results <- scraperResult{
 		source:   name,
 		articles: articles,
 		err:      err,
+		run:      run,
 	}
 }
 
-func collectResults(results <-chan scraperResult) ([]model.Article, []error) {
+func collectResults(results <-chan scraperResult) ([]model.Article, []error, []model.ScrapeRun) {
 	var allArticles []model.Article
 	var errors []error
+	var runs []model.ScrapeRun
 
 	for result := range results {
+		runs = append(runs, result.run)
 		if result.err != nil {
 			utils.Log(utils.Scraper, result.source+" scraping failed", "error", result.err)
 			errors = append(errors, fmt.Errorf("%s: %w", result.source, result.err))
@@ -126,7 +121,7 @@ func collectResults(results <-chan scraperResult) ([]model.Article, []error) {
 		}
 	}
 
-	return allArticles, errors
+	return allArticles, errors, runs
 }
 
 func detectLanguages(articles []model.Article) {
@@ -137,15 +132,22 @@ func detectLanguages(articles []model.Article) {
 	}
 }
 
-func saveToDatabase(db *gorm.DB, articles []model.Article) error {
+// saveToDatabase stores scraped articles and returns the ones not stored
+// before.
+func saveToDatabase(db *gorm.DB, articles []model.Article) ([]model.Article, error) {
 	if len(articles) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Load existing articles
 	var existing []model.Article
 	if err := db.Find(&existing).Error; err != nil {
-		return err
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(existing))
+	for _, a := range existing {
+		known[a.ID] = true
 	}
 
 	// Link similar articles
@@ -156,20 +158,31 @@ func saveToDatabase(db *gorm.DB, articles []model.Article) error {
 
 	// Upsert articles in batches
 	if err := upsertArticles(db, articlesMap); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Persist associations
-	return persistAssociations(db, articles)
+	if err := persistAssociations(db, articles); err != nil {
+		return nil, err
+	}
+
+	added := make([]model.Article, 0)
+	for _, a := range articles {
+		if a.ID != "" && !known[a.ID] {
+			known[a.ID] = true
+			added = append(added, a)
+		}
+	}
+	return added, nil
 }
 
 func linkSimilarArticles(newArticles []model.Article, existingArticles []model.Article) {
 	config := utils.DefaultSimilarityConfig()
-	threshold := 0.3
 
 	// Link new articles to existing articles
 	for i := range newArticles {
 		for j := range existingArticles {
+			threshold := utils.LinkThresholdFor(newArticles[i], existingArticles[j])
 			if utils.IsSimilar(newArticles[i], existingArticles[j], threshold, config) && newArticles[i].ID != existingArticles[j].ID {
 				newArticles[i].LinkedTo = append(newArticles[i].LinkedTo, &existingArticles[j])
 			}
@@ -177,6 +190,7 @@ func linkSimilarArticles(newArticles []model.Article, existingArticles []model.A
 
 		// Link new articles to each other
 		for j := i + 1; j < len(newArticles); j++ {
+			threshold := utils.LinkThresholdFor(newArticles[i], newArticles[j])
 			if utils.IsSimilar(newArticles[i], newArticles[j], threshold, config) && newArticles[i].ID != newArticles[j].ID {
 				newArticles[i].LinkedTo = append(newArticles[i].LinkedTo, &newArticles[j])
 			}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"news-swipe/backend/graph/model"
+	"os"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+var roleContextKey = &contextKey{"role"}
+
+// AuthMiddleware grants the admin role to requests carrying the ADMIN_TOKEN as
+// a bearer token. Every other request is treated as a regular user.
+func AuthMiddleware(next http.Handler) http.Handler {
+	adminToken := os.Getenv("ADMIN_TOKEN")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := model.UserRoleUser
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok && adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			role = model.UserRoleAdmin
+		}
+
+		ctx := context.WithValue(r.Context(), roleContextKey, role)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRoleFromContext retrieves the caller's role from the request context
+func GetRoleFromContext(ctx context.Context) model.UserRole {
+	if role, ok := ctx.Value(roleContextKey).(model.UserRole); ok {
+		return role
+	}
+	return model.UserRoleUser
+}
+
+// HasRoleDirective implements @hasRole. Admins may access every field.
+func HasRoleDirective(ctx context.Context, obj any, next graphql.Resolver, role model.UserRole) (any, error) {
+	current := GetRoleFromContext(ctx)
+	if current != role && current != model.UserRoleAdmin {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Access denied: " + role.String() + " role required",
+			Extensions: map[string]any{
+				"code": 403,
+			},
+		}
+	}
+	return next(ctx)
+}
@@ -3,14 +3,19 @@ package graph
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"news-swipe/backend/graph/model"
 	"news-swipe/backend/utils"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
 )
 
 // RedisCacheMiddleware wraps the GraphQL handler with Redis caching
@@ -22,8 +27,10 @@ func RedisCacheMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Skip caching if Redis is not available
-		if utils.RedisClient == nil {
+		// Skip caching if Redis is not available, for subscriptions and for
+		// authenticated requests, whose responses must not be served to
+		// other clients
+		if utils.RedisClient == nil || isWebsocketUpgrade(r) || GetRoleFromContext(r.Context()) != model.UserRoleUser {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -38,18 +45,26 @@ func RedisCacheMiddleware(next http.Handler) http.Handler {
 		ctx := r.Context()
 
 		// Try to get from cache
-		cached, err := utils.RedisClient.Get(ctx, cacheKey).Result()
-		if err == nil {
-			// Cache hit
+		cached, err := utils.RedisClient.Get(ctx, cacheKey).Bytes()
+		// Entries cached before impressions were stored with them have no
+		// body and are replaced
+		var entry cacheEntry
+		if err == nil && json.Unmarshal(cached, &entry) == nil && entry.Body != "" {
+			// Cache hit; the listed articles were seen again
+			utils.Views.RecordImpressionIDs(entry.Impressions)
 			w.Header().Set("Content-Type", "application/json")
 			w.Header().Set("X-Cache", "HIT")
-			w.Write([]byte(cached))
+			w.Write([]byte(entry.Body))
 			return
-		} else if err != redis.Nil {
+		} else if err != nil && err != redis.Nil {
 			// Redis error, continue without cache
 			utils.Log(utils.GraphQL, "Redis cache error:", err)
 		}
 
+		// Note the impressions of the response to count them on later hits
+		ctx, impressions := utils.WithImpressionLog(ctx)
+		r = r.WithContext(ctx)
+
 		// Cache miss - create response recorder
 		recorder := &responseRecorder{
 			ResponseWriter: w,
@@ -73,7 +88,10 @@ func RedisCacheMiddleware(next http.Handler) http.Handler {
 			}
 
 			// Store in Redis
-			err = utils.RedisClient.Set(ctx, cacheKey, recorder.body.String(), cacheDuration).Err()
+			entry, err := json.Marshal(cacheEntry{Body: recorder.body.String(), Impressions: impressions.IDs()})
+			if err == nil {
+				err = utils.RedisClient.Set(ctx, cacheKey, entry, cacheDuration).Err()
+			}
 			if err != nil {
 				utils.Log(utils.GraphQL, "Failed to cache response:", err)
 			}
@@ -91,6 +109,12 @@ func RedisCacheMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// cacheEntry is a cached response together with the articles it lists
+type cacheEntry struct {
+	Body        string   `json:"body"`
+	Impressions []string `json:"impressions,omitempty"`
+}
+
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
@@ -138,13 +162,30 @@ func generateCacheKey(r *http.Request) (string, error) {
 		variables = reqBody.Variables
 	}
 
-	// Create cache key from query and variables
+	// Mutations must reach their resolver every time
+	if isMutation(query) {
+		return "", errNotCacheable
+	}
+
+	// The Filter and Accept-Language headers change the result as well
+	sources := make([]string, 0)
+	for _, s := range GetSourcesFromContext(r.Context()) {
+		sources = append(sources, s.String())
+	}
+	sort.Strings(sources)
+	language, _ := GetLanguageFromContext(r.Context()).Value()
+
+	// Create cache key from query, variables and headers
 	keyData := struct {
 		Query     string                 `json:"query"`
 		Variables map[string]interface{} `json:"variables"`
+		Sources   []string               `json:"sources"`
+		Language  interface{}            `json:"language"`
 	}{
 		Query:     query,
 		Variables: variables,
+		Sources:   sources,
+		Language:  language,
 	}
 
 	keyBytes, err := json.Marshal(keyData)
@@ -156,6 +197,23 @@ func generateCacheKey(r *http.Request) (string, error) {
 	return fmt.Sprintf("gql:cache:%x", hash), nil
 }
 
+var errNotCacheable = errors.New("operation is not cacheable")
+
+// isMutation reports whether a document contains a mutation. Documents that
+// fail to parse are left to the handler to reject.
+func isMutation(query string) bool {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return false
+	}
+	for _, op := range doc.Operations {
+		if op.Operation == ast.Mutation {
+			return true
+		}
+	}
+	return false
+}
+
 func parseCacheControl(cacheControl string) time.Duration {
 	// Parse "max-age=XXX" from cache-control header
 	parts := strings.Split(cacheControl, ",")
@@ -0,0 +1,255 @@
+package graph
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"news-swipe/backend/graph/model"
+	"news-swipe/backend/utils"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes of operations rejected for their size. ErrComplexityLimit is
+// the code set by gqlgen's extension.ComplexityLimit.
+const (
+	ErrDepthLimit      = "DEPTH_LIMIT_EXCEEDED"
+	ErrComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+)
+
+// QueryLimitConfig bounds the work a single operation may cause. Relations
+// such as linkedTo and KeyWords.articles are recursive, so one request can
+// otherwise fan out far beyond what the rate limit accounts for.
+type QueryLimitConfig struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+var queryLimitConfig = QueryLimitConfig{
+	MaxDepth:      10,
+	MaxComplexity: 20000,
+}
+
+// Estimated number of items of relations that take no size argument. They
+// only need to be in the right order of magnitude to price nesting.
+const (
+	linksPerArticle    = 5
+	keywordsPerArticle = 10
+	entitiesPerArticle = 10
+	articlesPerKeyword = 20
+	articlesPerEntity  = 20
+	articlesPerStory   = 20
+	listedKeywords     = 50
+	listedBlindspots   = 20
+)
+
+func init() {
+	// Answer rejected operations with 422 like other invalid operations
+	errcode.RegisterErrorType(ErrDepthLimit, errcode.KindProtocol)
+	errcode.RegisterErrorType(ErrComplexityLimit, errcode.KindProtocol)
+}
+
+// loadQueryLimitConfig reads the query limits from the environment. It runs
+// when the server starts rather than at init, after the .env file is loaded.
+func loadQueryLimitConfig() {
+	if depth := os.Getenv("GRAPHQL_MAX_DEPTH"); depth != "" {
+		if val, err := strconv.Atoi(depth); err == nil {
+			queryLimitConfig.MaxDepth = val
+		}
+	}
+	if complexity := os.Getenv("GRAPHQL_MAX_COMPLEXITY"); complexity != "" {
+		if val, err := strconv.Atoi(complexity); err == nil {
+			queryLimitConfig.MaxComplexity = val
+		}
+	}
+}
+
+// listCost prices a list of n items. It saturates instead of overflowing so
+// deeply nested queries cannot wrap around to a small cost.
+func listCost(n, childComplexity int) int {
+	if n < 1 {
+		n = 1
+	}
+	if childComplexity < 1 {
+		childComplexity = 1
+	}
+	const maxCost = int(^uint(0) >> 1)
+	if childComplexity > (maxCost-1)/n {
+		return maxCost
+	}
+	return 1 + n*childComplexity
+}
+
+// amountCost prices a list the way ListSize clamps it, so amounts the
+// resolver cannot honour cost as much as the largest list
+func amountCost(amount int32, childComplexity int) int {
+	return listCost(utils.ListSize(amount), childComplexity)
+}
+
+// optionalAmountCost prices a list whose resolver falls back to a default
+// size when the amount is missing or not positive
+func optionalAmountCost(amount *int32, fallback int, childComplexity int) int {
+	if amount == nil || *amount < 1 {
+		return listCost(fallback, childComplexity)
+	}
+	return listCost(int(*amount), childComplexity)
+}
+
+// pageCost prices a connection page the way PageSize clamps it
+func pageCost(first *int32, childComplexity int) int {
+	return listCost(utils.PageSize(first), childComplexity)
+}
+
+// setComplexity installs the cost functions of list fields. Fields without
+// one cost 1 plus their selection.
+func setComplexity(c *Config) {
+	c.Complexity.Article.LinkedTo = func(childComplexity int) int {
+		return listCost(linksPerArticle, childComplexity)
+	}
+	c.Complexity.Article.LinkedFrom = func(childComplexity int) int {
+		return listCost(linksPerArticle, childComplexity)
+	}
+	c.Complexity.Article.Keywords = func(childComplexity int) int {
+		return listCost(keywordsPerArticle, childComplexity)
+	}
+	c.Complexity.Article.Entities = func(childComplexity int) int {
+		return listCost(entitiesPerArticle, childComplexity)
+	}
+	c.Complexity.KeyWords.Articles = func(childComplexity int) int {
+		return listCost(articlesPerKeyword, childComplexity)
+	}
+	c.Complexity.ResponseKeyWords.Articles = func(childComplexity int) int {
+		return listCost(articlesPerKeyword, childComplexity)
+	}
+	c.Complexity.Entity.Articles = func(childComplexity int) int {
+		return listCost(articlesPerEntity, childComplexity)
+	}
+	c.Complexity.Story.Articles = func(childComplexity int) int {
+		return listCost(articlesPerStory, childComplexity)
+	}
+
+	// articles returns every article; price it like the largest page
+	c.Complexity.Query.Articles = func(childComplexity int, filter *model.ArticleFilter) int {
+		return listCost(utils.MaxPageSize, childComplexity)
+	}
+	c.Complexity.Query.TopArticles = func(childComplexity int, amount int32, filter *model.ArticleFilter, sort *model.ArticleSort) int {
+		return amountCost(amount, childComplexity)
+	}
+	c.Complexity.Query.RecentArticle = func(childComplexity int, amount int32, filter *model.ArticleFilter) int {
+		return amountCost(amount, childComplexity)
+	}
+	c.Complexity.Query.NextRecentArticle = func(childComplexity int, start int32, stop int32, filter *model.ArticleFilter) int {
+		return amountCost(stop-start, childComplexity)
+	}
+	c.Complexity.Query.Nodes = func(childComplexity int, ids []string) int {
+		return listCost(len(ids), childComplexity)
+	}
+	c.Complexity.Query.BatchFindArticles = func(childComplexity int, ids []*string, filter *model.ArticleFilter) int {
+		return listCost(len(ids), childComplexity)
+	}
+	c.Complexity.Query.RecentArticles = func(childComplexity int, first *int32, after *string, filter *model.ArticleFilter) int {
+		return pageCost(first, childComplexity)
+	}
+	c.Complexity.Query.PopularArticles = func(childComplexity int, first *int32, after *string, filter *model.ArticleFilter, sort *model.ArticleSort) int {
+		return pageCost(first, childComplexity)
+	}
+	c.Complexity.Query.KeywordArticles = func(childComplexity int, id string, first *int32, after *string, filter *model.ArticleFilter) int {
+		return pageCost(first, childComplexity)
+	}
+	c.Complexity.Query.Search = func(childComplexity int, query string, language *model.Language, sources []model.Source, from *time.Time, to *time.Time, first *int32, after *string, filter *model.ArticleFilter) int {
+		return pageCost(first, childComplexity)
+	}
+	c.Complexity.Query.LinkedArticles = func(childComplexity int, id string, crossLanguage *bool, filter *model.ArticleFilter) int {
+		return listCost(similarArticlesLimit, childComplexity)
+	}
+	c.Complexity.Query.SimilarArticles = func(childComplexity int, id string, amount *int32, filter *model.ArticleFilter) int {
+		return optionalAmountCost(amount, 10, childComplexity)
+	}
+	c.Complexity.Mutation.LinkSimilarArticles = func(childComplexity int, id string, amount *int32) int {
+		return optionalAmountCost(amount, 10, childComplexity)
+	}
+	c.Complexity.Query.Keywords = func(childComplexity int) int {
+		return listCost(listedKeywords, childComplexity)
+	}
+	c.Complexity.Query.TrendingKeywords = func(childComplexity int, window *model.TrendWindow, amount *int32) int {
+		return optionalAmountCost(amount, 10, childComplexity)
+	}
+	c.Complexity.Query.RelatedKeywords = func(childComplexity int, id string, limit *int32) int {
+		return optionalAmountCost(limit, 10, childComplexity)
+	}
+	c.Complexity.Query.Stories = func(childComplexity int, amount int32, filter *model.ArticleFilter) int {
+		return amountCost(amount, childComplexity)
+	}
+	c.Complexity.Query.Blindspots = func(childComplexity int, since *time.Time) int {
+		return listCost(listedBlindspots, childComplexity)
+	}
+}
+
+// complexityLimit rejects operations whose estimated cost exceeds the
+// configured limit.
+func complexityLimit() *extension.ComplexityLimit {
+	return extension.FixedComplexityLimit(queryLimitConfig.MaxComplexity)
+}
+
+// DepthLimit rejects operations whose selections nest deeper than MaxDepth.
+// Introspection fields are not counted since the standard introspection
+// query is deeply nested.
+type DepthLimit struct {
+	MaxDepth int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = DepthLimit{}
+
+func (d DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d DepthLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	op := opCtx.Doc.Operations.ForName(opCtx.OperationName)
+	if op == nil {
+		return nil
+	}
+
+	if depth := selectionDepth(op.SelectionSet); depth > d.MaxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.MaxDepth)
+		errcode.Set(err, ErrDepthLimit)
+		return err
+	}
+	return nil
+}
+
+func selectionDepth(selectionSet ast.SelectionSet) int {
+	depth := 0
+	for _, selection := range selectionSet {
+		var d int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			d = 1 + selectionDepth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				d = selectionDepth(s.Definition.SelectionSet)
+			}
+		case *ast.InlineFragment:
+			d = selectionDepth(s.SelectionSet)
+		}
+		depth = max(depth, d)
+	}
+	return depth
+}
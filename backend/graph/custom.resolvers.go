@@ -1,43 +1,129 @@
 package graph
 
 import (
+	"errors"
 	"gorm.io/gorm"
 	"context"
 	"news-swipe/backend/graph/model"
 	"news-swipe/backend/utils"
 
-	"github.com/99designs/gqlgen/graphql"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
+// similarArticlesLimit caps how many trigram matches are computed per article
+const similarArticlesLimit = 50
+
+// similarLimit returns the number of trigram matches asked for, 10 by default
+func similarLimit(amount *int32) int {
+	limit := 10
+	if amount != nil && *amount > 0 {
+		limit = int(*amount)
+	}
+	if limit > similarArticlesLimit {
+		limit = similarArticlesLimit
+	}
+	return limit
+}
+
 type Resolver struct {
 	DB *gorm.DB
 }
 
-func (r *queryResolver) UpdateViews(ctx context.Context, articles []*model.Article) {
-	if len(articles) == 0 {
-		return
+// articleFilter completes the filter argument of a listing with the sources of
+// the Filter header and the language of the Accept-Language header.
+// Paywalled articles are excluded unless the client asks for them.
+func articleFilter(ctx context.Context, filter *model.ArticleFilter) *model.ArticleFilter {
+	var f model.ArticleFilter
+	if filter != nil {
+		f = *filter
+	}
+	if f.ExcludePaywalled == nil {
+		exclude := true
+		f.ExcludePaywalled = &exclude
+	}
+	if f.Language == nil {
+		lang := GetLanguageFromContext(ctx)
+		f.Language = &lang
+	}
+	if len(f.Sources) == 0 {
+		f.Sources = GetSourcesFromContext(ctx)
 	}
+	return &f
+}
+
+// relationFilter is the filter of articles reached through another object
+// or by ID. Paywalled articles and sources outside the Filter header are
+// hidden as in listings, but every language is kept since relations cross
+// languages.
+func relationFilter(ctx context.Context) *model.ArticleFilter {
+	exclude := true
+	return &model.ArticleFilter{ExcludePaywalled: &exclude, Sources: GetSourcesFromContext(ctx)}
+}
 
-	ids := make([]string, len(articles))
-	for i, article := range articles {
-		ids[i] = article.ID
+// filteredArticles scopes a preload of articles to a filter
+func filteredArticles(filter *model.ArticleFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB { return utils.ApplyArticleFilter(db, filter) }
+}
+
+// articleConnection pages through the articles selected by query and counts
+// the page as viewed like the list queries do.
+func (r *queryResolver) articleConnection(ctx context.Context, query *gorm.DB, order utils.ArticleOrder, first *int32, after *string) (*model.ArticleConnection, error) {
+	conn, err := utils.PaginateArticles(query, order, first, after)
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		return nil, utils.GqlError("Failed to fetch articles", err.Error(), 400, ctx)
+	}
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to fetch articles", errStr, code, ctx)
 	}
 
-	err := r.DB.Exec(`
-		UPDATE articles
-		SET views = views + 1
-		WHERE id IN ?
-	`, ids).Error
+	articles := make([]*model.Article, len(conn.Edges))
+	for i, edge := range conn.Edges {
+		articles[i] = edge.Node
+	}
+	utils.Views.RecordImpressions(ctx, articles)
+	return conn, nil
+}
 
+func (r *Resolver) keywordHistory(ctx context.Context, keywordID string, window *model.TrendWindow) ([]*model.KeywordTrendPoint, error) {
+	w := model.TrendWindowDay
+	if window != nil {
+		w = *window
+	}
+
+	points, err := utils.KeywordHistory(r.DB, keywordID, utils.TrendWindowDuration(w))
 	if err != nil {
 		errStr, code := utils.HandleGormError(err)
-		graphql.AddError(ctx, &gqlerror.Error{
-			Path: graphql.GetPath(ctx),
-			Message: errStr,
-			Extensions: map[string]any{
-				"code": code,
-			},
-		})
+		return nil, utils.GqlError("Failed to load keyword history", errStr, code, ctx)
+	}
+	return points, nil
+}
+
+func (r *Resolver) responseKeyword(ctx context.Context, kw *model.KeyWords) (*model.ResponseKeyWords, error) {
+	var articles []*model.Article
+	if err := utils.ApplyArticleFilter(r.DB.Model(kw), articleFilter(ctx, nil)).Association("Articles").Find(&articles); err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load keyword articles", errStr, code, ctx)
+	}
+
+	return &model.ResponseKeyWords{
+		ID:         kw.ID,
+		Keyword:    kw.Keyword,
+		LastUpdate: kw.LastUpdate,
+		Articles:   articles,
+	}, nil
+}
+
+// keywordCurationError maps curation failures onto status codes
+func keywordCurationError(ctx context.Context, msg string, err error) *gqlerror.Error {
+	switch {
+	case errors.Is(err, utils.ErrInvalidKeyword), errors.Is(err, utils.ErrSameKeyword),
+		errors.Is(err, utils.ErrLanguageMismatch):
+		return utils.GqlError(msg, err.Error(), 400, ctx)
+	case errors.Is(err, utils.ErrKeywordConflict):
+		return utils.GqlError(msg, err.Error(), 409, ctx)
+	default:
+		errStr, code := utils.HandleGormError(err)
+		return utils.GqlError(msg, errStr, code, ctx)
 	}
 }
@@ -0,0 +1,268 @@
+package graph
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"news-swipe/backend/graph/model"
+	"news-swipe/backend/utils"
+
+	"github.com/99designs/gqlgen/graphql"
+	"gorm.io/gorm"
+)
+
+const (
+	// loaderWait is how long a loader waits for another key before fetching.
+	// gqlgen resolves the fields of a list concurrently, so siblings arrive
+	// within this window of each other.
+	loaderWait = 2 * time.Millisecond
+	// loaderMaxWait bounds the wait of the first key while more keep arriving
+	loaderMaxWait = 10 * time.Millisecond
+	// loaderMaxBatch bounds the size of the IN clause of a single fetch
+	loaderMaxBatch = 500
+)
+
+var loadersContextKey = &contextKey{"loaders"}
+
+// loader batches the keys requested while one level of a query is resolved
+// into a single fetch and caches the results for the rest of the response.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu    sync.Mutex
+	batch *loaderBatch[K, V]
+	cache map[K]V
+}
+
+type loaderBatch[K comparable, V any] struct {
+	// deadline is when the batch is fetched unless another key arrives
+	deadline time.Time
+	maxWait  time.Time
+
+	keys    []K
+	seen    map[K]bool
+	done    chan struct{}
+	results map[K]V
+	err     error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, cache: make(map[K]V)}
+}
+
+// Load returns the value for key, which is the zero value when the fetch
+// found nothing for it.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	if v, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return v, nil
+	}
+
+	now := time.Now()
+	b := l.batch
+	if b == nil {
+		b = &loaderBatch[K, V]{maxWait: now.Add(loaderMaxWait), seen: make(map[K]bool), done: make(chan struct{})}
+		l.batch = b
+		go l.wait(ctx, b)
+	}
+	if !b.seen[key] {
+		b.seen[key] = true
+		b.keys = append(b.keys, key)
+		b.deadline = now.Add(loaderWait)
+		if b.deadline.After(b.maxWait) {
+			b.deadline = b.maxWait
+		}
+	}
+	if len(b.keys) >= loaderMaxBatch {
+		l.batch = nil
+		go l.dispatch(ctx, b)
+	}
+	l.mu.Unlock()
+
+	<-b.done
+	return b.results[key], b.err
+}
+
+// wait dispatches a batch once no key arrived for loaderWait. Before giving
+// up on more keys it yields once, so resolvers that are runnable but starved
+// of a CPU still join the batch.
+func (l *loader[K, V]) wait(ctx context.Context, b *loaderBatch[K, V]) {
+	yielded := false
+	for {
+		l.mu.Lock()
+		remaining := time.Until(b.deadline)
+		l.mu.Unlock()
+		if remaining > 0 {
+			time.Sleep(remaining)
+			yielded = false
+			continue
+		}
+		if yielded {
+			break
+		}
+		runtime.Gosched()
+		yielded = true
+	}
+	l.dispatch(ctx, b)
+}
+
+// dispatch runs the fetch for a batch once, whichever of the timer and the
+// size limit fires first.
+func (l *loader[K, V]) dispatch(ctx context.Context, b *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if l.batch == b {
+		l.batch = nil
+	}
+	if b.results != nil || b.err != nil {
+		l.mu.Unlock()
+		return
+	}
+	// Mark the batch as taken before fetching so the other trigger skips it
+	b.results = make(map[K]V)
+	l.mu.Unlock()
+
+	results, err := l.fetch(ctx, b.keys)
+
+	l.mu.Lock()
+	if err == nil {
+		b.results = results
+		for _, k := range b.keys {
+			l.cache[k] = results[k]
+		}
+	}
+	b.err = err
+	l.mu.Unlock()
+	close(b.done)
+}
+
+// Loaders batch the relations of articles. A fresh set is created for every
+// response so cached values never outlive it.
+type Loaders struct {
+	LinkedTo   *loader[string, []*model.Article]
+	LinkedFrom *loader[string, []*model.Article]
+	Keywords   *loader[string, []*model.KeyWords]
+	Entities   *loader[string, []*model.Entity]
+	Stories    *loader[string, *model.Story]
+}
+
+func NewLoaders(db *gorm.DB) *Loaders {
+	return &Loaders{
+		LinkedTo:   newLoader(linkedArticlesFetcher(db, "article_links.linked_article_id", "article_links.article_id")),
+		LinkedFrom: newLoader(linkedArticlesFetcher(db, "article_links.article_id", "article_links.linked_article_id")),
+		Keywords:   newLoader(articleKeywordsFetcher(db)),
+		Entities:   newLoader(articleEntitiesFetcher(db)),
+		Stories:    newLoader(storiesFetcher(db)),
+	}
+}
+
+// LoadersResponseMiddleware attaches new loaders to every response, including
+// each event of a subscription.
+func LoadersResponseMiddleware(db *gorm.DB) graphql.ResponseMiddleware {
+	return func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		return next(context.WithValue(ctx, loadersContextKey, NewLoaders(db)))
+	}
+}
+
+// GetLoadersFromContext returns the loaders of the current response
+func GetLoadersFromContext(ctx context.Context) *Loaders {
+	return ctx.Value(loadersContextKey).(*Loaders)
+}
+
+// linkedArticle is an article together with the article it was loaded for
+type linkedArticle struct {
+	model.Article
+	LinkKey string
+}
+
+// linkedArticlesFetcher loads the articles on one side of article_links for
+// the articles on the other side, hidden like other related articles.
+func linkedArticlesFetcher(db *gorm.DB, target, key string) func(ctx context.Context, ids []string) (map[string][]*model.Article, error) {
+	return func(ctx context.Context, ids []string) (map[string][]*model.Article, error) {
+		var rows []linkedArticle
+		if err := utils.ApplyArticleFilter(db.WithContext(ctx).Model(&model.Article{}), relationFilter(ctx)).
+			Select("articles.*, "+key+" AS link_key").
+			Joins("JOIN article_links ON "+target+" = articles.id").
+			Where(key+" IN ?", ids).
+			Order("articles.published_at DESC").
+			Find(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		result := make(map[string][]*model.Article, len(ids))
+		for i := range rows {
+			result[rows[i].LinkKey] = append(result[rows[i].LinkKey], &rows[i].Article)
+		}
+		return result, nil
+	}
+}
+
+type articleKeyword struct {
+	model.KeyWords
+	LinkKey string
+}
+
+// articleKeywordsFetcher loads the active keywords of articles
+func articleKeywordsFetcher(db *gorm.DB) func(ctx context.Context, ids []string) (map[string][]*model.KeyWords, error) {
+	return func(ctx context.Context, ids []string) (map[string][]*model.KeyWords, error) {
+		var rows []articleKeyword
+		if err := db.WithContext(ctx).Model(&model.KeyWords{}).
+			Select("key_words.*, article_keywords.article_id AS link_key").
+			Joins("JOIN article_keywords ON article_keywords.key_words_id = key_words.id").
+			Where("article_keywords.article_id IN ? AND key_words.expired_at IS NULL", ids).
+			Order("key_words.keyword ASC").
+			Find(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		result := make(map[string][]*model.KeyWords, len(ids))
+		for i := range rows {
+			result[rows[i].LinkKey] = append(result[rows[i].LinkKey], &rows[i].KeyWords)
+		}
+		return result, nil
+	}
+}
+
+type articleEntity struct {
+	model.Entity
+	LinkKey string
+}
+
+// articleEntitiesFetcher loads the named entities of articles
+func articleEntitiesFetcher(db *gorm.DB) func(ctx context.Context, ids []string) (map[string][]*model.Entity, error) {
+	return func(ctx context.Context, ids []string) (map[string][]*model.Entity, error) {
+		var rows []articleEntity
+		if err := db.WithContext(ctx).Model(&model.Entity{}).
+			Select("entities.*, article_entities.article_id AS link_key").
+			Joins("JOIN article_entities ON article_entities.entity_id = entities.id").
+			Where("article_entities.article_id IN ?", ids).
+			Order("entities.name ASC").
+			Find(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		result := make(map[string][]*model.Entity, len(ids))
+		for i := range rows {
+			result[rows[i].LinkKey] = append(result[rows[i].LinkKey], &rows[i].Entity)
+		}
+		return result, nil
+	}
+}
+
+// storiesFetcher loads stories by their IDs
+func storiesFetcher(db *gorm.DB) func(ctx context.Context, ids []string) (map[string]*model.Story, error) {
+	return func(ctx context.Context, ids []string) (map[string]*model.Story, error) {
+		var stories []*model.Story
+		if err := db.WithContext(ctx).Where("id IN ?", ids).Find(&stories).Error; err != nil {
+			return nil, err
+		}
+
+		result := make(map[string]*model.Story, len(stories))
+		for _, s := range stories {
+			result[s.ID] = s
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,232 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The fake driver answers every query with canned rows chosen by the tables
+// it reads, so resolvers run end to end without a database.
+
+const pageSize = 3
+
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("prepare not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("transactions not supported") }
+
+func (fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	ids := make([]string, 0, len(args))
+	for _, arg := range args {
+		if id, ok := arg.Value.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	published := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	article := func(id string) []driver.Value {
+		return []driver.Value{id, "Title " + id, string(model.SourceWelt), published, "DE", "story-" + id}
+	}
+	articleColumns := []string{"id", "title", "source", "published_at", "language", "story_id"}
+
+	rows := &fakeRows{}
+	switch {
+	case strings.Contains(query, "article_entities"):
+		rows.columns = []string{"id", "name", "type", "link_key"}
+		for _, id := range ids {
+			rows.values = append(rows.values, []driver.Value{"entity-" + id, "Berlin", string(model.EntityTypeLocation), id})
+		}
+	case strings.Contains(query, "article_keywords"):
+		rows.columns = []string{"id", "keyword", "link_key"}
+		for _, id := range ids {
+			rows.values = append(rows.values, []driver.Value{"keyword-" + id, "Wahl", id})
+		}
+	case strings.Contains(query, "article_links"):
+		rows.columns = append(articleColumns, "link_key")
+		for _, id := range ids {
+			for _, suffix := range []string{"-a", "-b"} {
+				rows.values = append(rows.values, append(article(id+suffix), id))
+			}
+		}
+	case strings.Contains(query, `FROM "stories"`):
+		rows.columns = []string{"id", "headline"}
+		for _, id := range ids {
+			rows.values = append(rows.values, []driver.Value{id, "Story " + id})
+		}
+	case strings.Contains(query, `FROM "articles"`):
+		rows.columns = articleColumns
+		for _, id := range []string{"1", "2", "3"} {
+			rows.values = append(rows.values, article(id))
+		}
+	default:
+		return nil, errors.New("unexpected query: " + query)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+// queryLog records the statements GORM runs
+type queryLog struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (l *queryLog) count(table string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := 0
+	for _, q := range l.queries {
+		if strings.Contains(q, table) {
+			n++
+		}
+	}
+	return n
+}
+
+func newCountingDB(t *testing.T) (*gorm.DB, *queryLog) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fakeConnector{})}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := &queryLog{}
+	if err := db.Callback().Query().After("gorm:query").Register("test:count_queries", func(tx *gorm.DB) {
+		log.mu.Lock()
+		log.queries = append(log.queries, tx.Statement.SQL.String())
+		log.mu.Unlock()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return db, log
+}
+
+func TestLoadersBatchOneQueryPerLevel(t *testing.T) {
+	db, log := newCountingDB(t)
+
+	c := Config{Resolvers: &Resolver{DB: db}}
+	c.Directives.HasRole = HasRoleDirective
+	srv := handler.New(NewExecutableSchema(c))
+	srv.AddTransport(transport.POST{})
+	srv.AroundResponses(LoadersResponseMiddleware(db))
+
+	body := `{"query": "{ recentArticles(first: ` + strconv.Itoa(pageSize) + `) { edges { node { entities { name } story { headline } linkedTo { keywords { keyword } } linkedFrom { id } } } } }"}`
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp struct {
+		Data struct {
+			RecentArticles struct {
+				Edges []struct {
+					Node struct {
+						Entities []struct{ Name string }
+						Story    *struct{ Headline string }
+						LinkedTo []struct {
+							Keywords []struct{ Keyword string }
+						}
+						LinkedFrom []struct{ ID string }
+					}
+				}
+			}
+		}
+		Errors []json.RawMessage
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v\n%s", err, rec.Body.String())
+	}
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %s", rec.Body.String())
+	}
+
+	edges := resp.Data.RecentArticles.Edges
+	if len(edges) != pageSize {
+		t.Fatalf("got %d articles, want %d", len(edges), pageSize)
+	}
+	for _, e := range edges {
+		if len(e.Node.Entities) != 1 || e.Node.Story == nil || len(e.Node.LinkedTo) != 2 || len(e.Node.LinkedFrom) != 2 {
+			t.Fatalf("relations not resolved: %+v", e.Node)
+		}
+		for _, linked := range e.Node.LinkedTo {
+			if len(linked.Keywords) != 1 {
+				t.Fatalf("keywords of linked article not resolved: %+v", linked)
+			}
+		}
+	}
+
+	// One query for the page, one per relation of its articles and one for
+	// the keywords of all linked articles, however many articles there are
+	levels := []struct {
+		name  string
+		table string
+	}{
+		{"entities", "article_entities"},
+		{"story", `FROM "stories"`},
+		{"linkedTo and linkedFrom", "article_links"},
+		{"keywords", "article_keywords"},
+	}
+	for _, level := range levels {
+		want := 1
+		if level.table == "article_links" {
+			want = 2
+		}
+		if got := log.count(level.table); got != want {
+			t.Errorf("%s: got %d queries, want %d", level.name, got, want)
+		}
+	}
+	if got := len(log.queries); got != 6 {
+		t.Errorf("got %d queries in total, want 6:\n%s", got, strings.Join(log.queries, "\n"))
+	}
+}
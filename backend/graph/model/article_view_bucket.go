@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// ArticleViewBucket counts the opens of an article per hour. The buckets
+// feed the recent views of the trending score.
+type ArticleViewBucket struct {
+	ArticleID string    `gorm:"primaryKey"`
+	Bucket    time.Time `gorm:"primaryKey;index"`
+	Opens     int32     `gorm:"not null;default:0"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// KeywordEdge connects two keywords that share articles. Edges are stored in
+// both directions so the neighbours of a keyword are a single lookup.
+type KeywordEdge struct {
+	KeyWordsID     string `gorm:"primaryKey"`
+	RelatedID      string `gorm:"primaryKey;index"`
+	SharedArticles int32
+	// Weight is the normalized pointwise mutual information of the pair
+	Weight    float64 `gorm:"index"`
+	UpdatedAt time.Time
+}
@@ -111,7 +111,7 @@ func FromLingua(lang lingua.Language) Language {
 
 func MarshalLanguage(lang Language) graphql.Marshaler {
 	return graphql.WriterFunc(func(w io.Writer) {
-		langStr := strings.ToUpper(string(lang))
+		langStr := strings.ToUpper(string(rune(lang)))
 		w.Write([]byte(fmt.Sprintf(`"%s"`, langStr)))
 	})
 }
@@ -12,32 +12,152 @@ import (
 	"github.com/lib/pq"
 )
 
+// An object that can be refetched by its global ID with node(id)
+type Node interface {
+	IsNode()
+	GetID() string
+}
+
 type Article struct {
 	GormModel
-	Title       string         `json:"title"`
-	Source      Source         `json:"source" gorm:"index"`
-	PublishedAt time.Time      `json:"publishedAt" gorm:"index"`
-	URI         string         `json:"uri"`
-	Views       int32          `json:"views" gorm:"index"`
-	Description string         `json:"description"`
-	Banner      string         `json:"banner"`
-	LinkedTo    []*Article     `json:"linkedTo,omitempty" gorm:"many2many:article_links;joinForeignKey:ArticleID;joinReferences:LinkedArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Category    pq.StringArray `json:"category,omitempty" gorm:"type:text[]"`
-	Language    Language       `json:"language" gorm:"index"`
-	Keywords    []*KeyWords    `json:"keywords,omitempty" gorm:"many2many:article_keywords;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	LinkedFrom  []*Article     `json:"-" gorm:"many2many:article_links;joinForeignKey:LinkedArticleID;joinReferences:ArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Title       string    `json:"title"`
+	Source      Source    `json:"source" gorm:"index"`
+	PublishedAt time.Time `json:"publishedAt" gorm:"index"`
+	URI         string    `json:"uri"`
+	// Times the article was opened, counted once per client within the dedup window
+	Views int32 `json:"views" gorm:"index"`
+	// Times the article was returned in a listing
+	Impressions int32 `json:"impressions" gorm:"not null;default:0"`
+	// Recent opens and coverage by other outlets, decayed by age; refreshed periodically
+	TrendingScore       float64        `json:"trendingScore" gorm:"not null;default:0;index"`
+	Description         string         `json:"description"`
+	Banner              string         `json:"banner"`
+	LinkedTo            []*Article     `json:"linkedTo,omitempty" gorm:"many2many:article_links;joinForeignKey:ArticleID;joinReferences:LinkedArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Category            pq.StringArray `json:"category,omitempty" gorm:"type:text[]"`
+	Language            Language       `json:"language" gorm:"index"`
+	Paywalled           bool           `json:"paywalled" gorm:"not null;default:false;index"`
+	Keywords            []*KeyWords    `json:"keywords,omitempty" gorm:"many2many:article_keywords;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	LinkedFrom          []*Article     `json:"-" gorm:"many2many:article_links;joinForeignKey:LinkedArticleID;joinReferences:ArticleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	StoryID             *string        `json:"-" gorm:"index"`
+	EntitiesExtractedAt *time.Time     `json:"-" gorm:"index"`
+}
+
+func (Article) IsNode()            {}
+func (this Article) GetID() string { return this.ID }
+
+type ArticleConnection struct {
+	Edges    []*ArticleEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type ArticleEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Article `json:"node"`
+}
+
+// Narrows an article listing. Without sources the Filter header applies, and
+// without a language the Accept-Language header.
+type ArticleFilter struct {
+	Sources []Source `json:"sources,omitempty"`
+	// Articles tagged with any of the categories
+	Categories []string   `json:"categories,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	Language   *Language  `json:"language,omitempty"`
+	// Paywalled articles are hidden unless this is set to false. Articles reached
+	// through other objects, such as the articles of a keyword, story or entity,
+	// are always hidden when paywalled, but still count toward keywords, stories
+	// and their coverage.
+	ExcludePaywalled *bool `json:"excludePaywalled,omitempty"`
+}
+
+type Blindspot struct {
+	Story          *Story   `json:"story"`
+	Leaning        Leaning  `json:"leaning"`
+	Skew           float64  `json:"skew"`
+	CoveredBy      []Source `json:"coveredBy"`
+	MissingSources []Source `json:"missingSources"`
 }
 
+type DailyArticleCount struct {
+	Date  time.Time `json:"date"`
+	Count int32     `json:"count"`
+}
+
+type Entity struct {
+	GormModel
+	Name     string     `json:"name" gorm:"uniqueIndex:idx_entities_name_type"`
+	Type     EntityType `json:"type" gorm:"uniqueIndex:idx_entities_name_type"`
+	Articles []*Article `json:"-" gorm:"many2many:article_entities;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (Entity) IsNode()            {}
+func (this Entity) GetID() string { return this.ID }
+
 type KeyWords struct {
 	GormModel
 	Keyword    string     `json:"keyword" gorm:"index"`
 	LastUpdate time.Time  `json:"lastUpdate"`
 	Articles   []*Article `json:"articles,omitempty" gorm:"many2many:article_keywords;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Normalized *string    `json:"-" gorm:"uniqueIndex:idx_key_words_normalized_language"`
+	Language   Language   `json:"-" gorm:"not null;uniqueIndex:idx_key_words_normalized_language"`
+	ExpiredAt  *time.Time `json:"-" gorm:"index"`
+	Pinned     bool       `json:"-" gorm:"not null;default:false"`
+	Blocked    bool       `json:"-" gorm:"not null;default:false"`
+}
+
+func (KeyWords) IsNode()            {}
+func (this KeyWords) GetID() string { return this.ID }
+
+type KeywordAlias struct {
+	Alias     string             `json:"alias" gorm:"primaryKey"`
+	Canonical string             `json:"canonical" gorm:"index"`
+	Score     float64            `json:"score"`
+	Status    KeywordAliasStatus `json:"status" gorm:"index"`
+	CreatedAt time.Time          `json:"-"`
+	UpdatedAt time.Time          `json:"-"`
+}
+
+type KeywordTrendPoint struct {
+	KeyWordsID   string    `json:"-" gorm:"primaryKey"`
+	Bucket       time.Time `json:"bucket" gorm:"primaryKey;index"`
+	ArticleCount int32     `json:"articleCount"`
+}
+
+type LinkExplanation struct {
+	ArticleA         *Article `json:"articleA"`
+	ArticleB         *Article `json:"articleB"`
+	Score            float64  `json:"score"`
+	Threshold        float64  `json:"threshold"`
+	Linked           bool     `json:"linked"`
+	TitleScore       float64  `json:"titleScore"`
+	DescriptionScore float64  `json:"descriptionScore"`
+	TimeScore        float64  `json:"timeScore"`
+	TimeBucket       string   `json:"timeBucket"`
+	SourceScore      float64  `json:"sourceScore"`
+	SameSource       bool     `json:"sameSource"`
+	SharedTokens     []string `json:"sharedTokens"`
+}
+
+type Mutation struct {
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
 }
 
 type Query struct {
 }
 
+type RelatedKeyword struct {
+	Keyword        *ResponseKeyWords `json:"keyword"`
+	SharedArticles int32             `json:"sharedArticles"`
+	Weight         float64           `json:"weight"`
+}
+
 type ResponseKeyWords struct {
 	ID         string     `json:"id"`
 	Keyword    string     `json:"keyword"`
@@ -45,6 +165,381 @@ type ResponseKeyWords struct {
 	Articles   []*Article `json:"articles"`
 }
 
+type ScoredArticle struct {
+	Article *Article `json:"article"`
+	Score   float64  `json:"score"`
+}
+
+type SearchConnection struct {
+	Edges    []*SearchEdge `json:"edges"`
+	PageInfo *PageInfo     `json:"pageInfo"`
+}
+
+type SearchEdge struct {
+	Cursor   string   `json:"cursor"`
+	Node     *Article `json:"node"`
+	Rank     float64  `json:"rank"`
+	Headline string   `json:"headline"`
+}
+
+type SourceCoverage struct {
+	Source       Source    `json:"source"`
+	Article      *Article  `json:"article"`
+	Headline     string    `json:"headline"`
+	Description  string    `json:"description"`
+	PublishedAt  time.Time `json:"publishedAt"`
+	DelayMinutes int32     `json:"delayMinutes"`
+	ArticleCount int32     `json:"articleCount"`
+}
+
+// An outlet with its metadata and scraping statistics
+type SourceInfo struct {
+	Source               Source     `json:"source"`
+	Name                 string     `json:"name"`
+	Homepage             string     `json:"homepage"`
+	LogoURL              *string    `json:"logoUrl,omitempty"`
+	Description          string     `json:"description"`
+	Leaning              Leaning    `json:"leaning"`
+	Health               FeedHealth `json:"health"`
+	LastSuccessfulScrape *time.Time `json:"lastSuccessfulScrape,omitempty"`
+	// Articles published per day over the last week, oldest first
+	ArticlesPerDay []*DailyArticleCount `json:"articlesPerDay"`
+	// Average seconds from publication until the article was scraped
+	AveragePublishLatency *float64 `json:"averagePublishLatency,omitempty"`
+}
+
+type Story struct {
+	GormModel
+	Headline     string         `json:"headline"`
+	FirstSeen    time.Time      `json:"firstSeen" gorm:"index"`
+	LastUpdated  time.Time      `json:"lastUpdated" gorm:"index"`
+	ArticleCount int32          `json:"articleCount"`
+	Sources      pq.StringArray `json:"-" gorm:"type:text[]"`
+}
+
+func (Story) IsNode()            {}
+func (this Story) GetID() string { return this.ID }
+
+type StoryCoverage struct {
+	Story          *Story            `json:"story"`
+	BrokenBy       Source            `json:"brokenBy"`
+	BrokenAt       time.Time         `json:"brokenAt"`
+	Sources        []*SourceCoverage `json:"sources"`
+	MissingSources []Source          `json:"missingSources"`
+}
+
+type Subscription struct {
+}
+
+type TrendingKeyword struct {
+	Keyword      *ResponseKeyWords `json:"keyword"`
+	ArticleCount int32             `json:"articleCount"`
+	Velocity     float64           `json:"velocity"`
+	Acceleration int32             `json:"acceleration"`
+}
+
+type WordList struct {
+	Kind     WordListKind `json:"kind"`
+	Language Language     `json:"language"`
+	Words    []string     `json:"words"`
+}
+
+type ArticleSort string
+
+const (
+	// By trending score: recent opens and coverage, decayed by age
+	ArticleSortTrending ArticleSort = "TRENDING"
+	// By all-time opens
+	ArticleSortViews ArticleSort = "VIEWS"
+	// Newest first
+	ArticleSortRecent ArticleSort = "RECENT"
+)
+
+var AllArticleSort = []ArticleSort{
+	ArticleSortTrending,
+	ArticleSortViews,
+	ArticleSortRecent,
+}
+
+func (e ArticleSort) IsValid() bool {
+	switch e {
+	case ArticleSortTrending, ArticleSortViews, ArticleSortRecent:
+		return true
+	}
+	return false
+}
+
+func (e ArticleSort) String() string {
+	return string(e)
+}
+
+func (e *ArticleSort) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ArticleSort(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ArticleSort", str)
+	}
+	return nil
+}
+
+func (e ArticleSort) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ArticleSort) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ArticleSort) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type EntityType string
+
+const (
+	EntityTypePerson       EntityType = "PERSON"
+	EntityTypeOrganization EntityType = "ORGANIZATION"
+	EntityTypeLocation     EntityType = "LOCATION"
+)
+
+var AllEntityType = []EntityType{
+	EntityTypePerson,
+	EntityTypeOrganization,
+	EntityTypeLocation,
+}
+
+func (e EntityType) IsValid() bool {
+	switch e {
+	case EntityTypePerson, EntityTypeOrganization, EntityTypeLocation:
+		return true
+	}
+	return false
+}
+
+func (e EntityType) String() string {
+	return string(e)
+}
+
+func (e *EntityType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EntityType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EntityType", str)
+	}
+	return nil
+}
+
+func (e EntityType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *EntityType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e EntityType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type FeedHealth string
+
+const (
+	// Recent scrapes succeeded
+	FeedHealthHealthy FeedHealth = "HEALTHY"
+	// Some recent scrapes failed
+	FeedHealthDegraded FeedHealth = "DEGRADED"
+	// No scrape succeeded recently
+	FeedHealthFailing FeedHealth = "FAILING"
+	// The source has not been scraped yet
+	FeedHealthUnknown FeedHealth = "UNKNOWN"
+)
+
+var AllFeedHealth = []FeedHealth{
+	FeedHealthHealthy,
+	FeedHealthDegraded,
+	FeedHealthFailing,
+	FeedHealthUnknown,
+}
+
+func (e FeedHealth) IsValid() bool {
+	switch e {
+	case FeedHealthHealthy, FeedHealthDegraded, FeedHealthFailing, FeedHealthUnknown:
+		return true
+	}
+	return false
+}
+
+func (e FeedHealth) String() string {
+	return string(e)
+}
+
+func (e *FeedHealth) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FeedHealth(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FeedHealth", str)
+	}
+	return nil
+}
+
+func (e FeedHealth) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *FeedHealth) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e FeedHealth) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type KeywordAliasStatus string
+
+const (
+	KeywordAliasStatusSuggested KeywordAliasStatus = "SUGGESTED"
+	KeywordAliasStatusActive    KeywordAliasStatus = "ACTIVE"
+)
+
+var AllKeywordAliasStatus = []KeywordAliasStatus{
+	KeywordAliasStatusSuggested,
+	KeywordAliasStatusActive,
+}
+
+func (e KeywordAliasStatus) IsValid() bool {
+	switch e {
+	case KeywordAliasStatusSuggested, KeywordAliasStatusActive:
+		return true
+	}
+	return false
+}
+
+func (e KeywordAliasStatus) String() string {
+	return string(e)
+}
+
+func (e *KeywordAliasStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = KeywordAliasStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid KeywordAliasStatus", str)
+	}
+	return nil
+}
+
+func (e KeywordAliasStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *KeywordAliasStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e KeywordAliasStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type Leaning string
+
+const (
+	LeaningLeft        Leaning = "LEFT"
+	LeaningCenterLeft  Leaning = "CENTER_LEFT"
+	LeaningCenter      Leaning = "CENTER"
+	LeaningCenterRight Leaning = "CENTER_RIGHT"
+	LeaningRight       Leaning = "RIGHT"
+)
+
+var AllLeaning = []Leaning{
+	LeaningLeft,
+	LeaningCenterLeft,
+	LeaningCenter,
+	LeaningCenterRight,
+	LeaningRight,
+}
+
+func (e Leaning) IsValid() bool {
+	switch e {
+	case LeaningLeft, LeaningCenterLeft, LeaningCenter, LeaningCenterRight, LeaningRight:
+		return true
+	}
+	return false
+}
+
+func (e Leaning) String() string {
+	return string(e)
+}
+
+func (e *Leaning) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Leaning(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Leaning", str)
+	}
+	return nil
+}
+
+func (e Leaning) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Leaning) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Leaning) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type Source string
 
 const (
@@ -110,6 +605,63 @@ func (e Source) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type TrendWindow string
+
+const (
+	TrendWindowHour TrendWindow = "HOUR"
+	TrendWindowDay  TrendWindow = "DAY"
+	TrendWindowWeek TrendWindow = "WEEK"
+)
+
+var AllTrendWindow = []TrendWindow{
+	TrendWindowHour,
+	TrendWindowDay,
+	TrendWindowWeek,
+}
+
+func (e TrendWindow) IsValid() bool {
+	switch e {
+	case TrendWindowHour, TrendWindowDay, TrendWindowWeek:
+		return true
+	}
+	return false
+}
+
+func (e TrendWindow) String() string {
+	return string(e)
+}
+
+func (e *TrendWindow) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TrendWindow(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TrendWindow", str)
+	}
+	return nil
+}
+
+func (e TrendWindow) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TrendWindow) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TrendWindow) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type UserRole string
 
 const (
@@ -166,3 +718,58 @@ func (e UserRole) MarshalJSON() ([]byte, error) {
 	e.MarshalGQL(&buf)
 	return buf.Bytes(), nil
 }
+
+type WordListKind string
+
+const (
+	WordListKindStopwords WordListKind = "STOPWORDS"
+	WordListKindBlocklist WordListKind = "BLOCKLIST"
+)
+
+var AllWordListKind = []WordListKind{
+	WordListKindStopwords,
+	WordListKindBlocklist,
+}
+
+func (e WordListKind) IsValid() bool {
+	switch e {
+	case WordListKindStopwords, WordListKindBlocklist:
+		return true
+	}
+	return false
+}
+
+func (e WordListKind) String() string {
+	return string(e)
+}
+
+func (e *WordListKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WordListKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WordListKind", str)
+	}
+	return nil
+}
+
+func (e WordListKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WordListKind) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WordListKind) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// ScrapeRun records one run of a source's scraper, mirroring the scraper
+// metrics so feed health survives restarts and is shared by all replicas.
+type ScrapeRun struct {
+	ID        uint      `gorm:"primaryKey"`
+	Source    Source    `gorm:"not null;index:idx_scrape_runs_source_started"`
+	StartedAt time.Time `gorm:"not null;index:idx_scrape_runs_source_started"`
+	Duration  time.Duration
+	Articles  int
+	// Error is empty for successful runs
+	Error string
+}
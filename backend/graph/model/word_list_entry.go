@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// WordListEntry is a stopword or blocked term added at runtime. Entries are
+// merged with the word list files on every reload.
+type WordListEntry struct {
+	Kind      WordListKind `gorm:"primaryKey"`
+	Language  Language     `gorm:"primaryKey"`
+	Word      string       `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
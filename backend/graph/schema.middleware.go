@@ -7,12 +7,14 @@ import (
 	"strings"
 
 	"news-swipe/backend/graph/model"
+	"news-swipe/backend/utils"
 
 	"github.com/pemistahl/lingua-go"
 )
 
 var filterContextKey = &contextKey{"filter"}
 var languageContextKey = &contextKey{"language"}
+var clientContextKey = &contextKey{"client"}
 
 type contextKey struct {
 	name string
@@ -51,6 +53,20 @@ func FilterMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// ClientMiddleware identifies the client for view deduplication by its
+// X-Client-ID header and its IP address
+func ClientMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := utils.ViewClient{
+			ID: strings.TrimSpace(r.Header.Get("X-Client-ID")),
+			IP: getClientIP(r),
+		}
+
+		ctx := context.WithValue(r.Context(), clientContextKey, client)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // LanguageMiddleware parses the Accept-Language header and stores the preferred language in context
 func LanguageMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -166,3 +182,17 @@ func GetLanguageFromContext(ctx context.Context) model.Language {
 	}
 	return model.FromLingua(lingua.German)
 }
+
+// GetSourcesFromContext retrieves the sources of the Filter header. Returns
+// nil when the header is missing, which means all sources.
+func GetSourcesFromContext(ctx context.Context) []model.Source {
+	sources, _ := ctx.Value(filterContextKey).([]model.Source)
+	return sources
+}
+
+// GetClientFromContext retrieves the client set by ClientMiddleware. Returns
+// an empty client when it is missing.
+func GetClientFromContext(ctx context.Context) utils.ViewClient {
+	client, _ := ctx.Value(clientContextKey).(utils.ViewClient)
+	return client
+}
@@ -6,9 +6,13 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"news-swipe/backend/graph/model"
+	"news-swipe/backend/scrapper"
 	"news-swipe/backend/utils"
+	"slices"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
@@ -16,15 +20,224 @@ import (
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
+// ID returns the global ID of an article.
+func (r *articleResolver) ID(ctx context.Context, obj *model.Article) (string, error) {
+	return utils.GlobalID(utils.NodeArticle, obj.ID), nil
+}
+
+// LinkedTo returns the articles an article links to, batched per response.
+func (r *articleResolver) LinkedTo(ctx context.Context, obj *model.Article) ([]*model.Article, error) {
+	articles, err := GetLoadersFromContext(ctx).LinkedTo.Load(ctx, obj.ID)
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load linked articles", errStr, code, ctx)
+	}
+	return articles, nil
+}
+
+// LinkedFrom returns the articles linking to an article, batched per response.
+func (r *articleResolver) LinkedFrom(ctx context.Context, obj *model.Article) ([]*model.Article, error) {
+	articles, err := GetLoadersFromContext(ctx).LinkedFrom.Load(ctx, obj.ID)
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load linking articles", errStr, code, ctx)
+	}
+	return articles, nil
+}
+
+// Keywords returns the active keywords of an article, batched per response.
+func (r *articleResolver) Keywords(ctx context.Context, obj *model.Article) ([]*model.KeyWords, error) {
+	keywords, err := GetLoadersFromContext(ctx).Keywords.Load(ctx, obj.ID)
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load keywords", errStr, code, ctx)
+	}
+	return keywords, nil
+}
+
+// Story returns the story an article belongs to, if it has been clustered,
+// batched per response.
+func (r *articleResolver) Story(ctx context.Context, obj *model.Article) (*model.Story, error) {
+	if obj.StoryID == nil {
+		return nil, nil
+	}
+
+	story, err := GetLoadersFromContext(ctx).Stories.Load(ctx, *obj.StoryID)
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load story", errStr, code, ctx)
+	}
+	return story, nil
+}
+
+// Entities returns the named entities mentioned in an article, batched per
+// response.
+func (r *articleResolver) Entities(ctx context.Context, obj *model.Article) ([]*model.Entity, error) {
+	entities, err := GetLoadersFromContext(ctx).Entities.Load(ctx, obj.ID)
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load entities", errStr, code, ctx)
+	}
+	return entities, nil
+}
+
+// ID returns the global ID of an entity.
+func (r *entityResolver) ID(ctx context.Context, obj *model.Entity) (string, error) {
+	return utils.GlobalID(utils.NodeEntity, obj.ID), nil
+}
+
+// Articles returns the articles mentioning an entity, newest first.
+func (r *entityResolver) Articles(ctx context.Context, obj *model.Entity) ([]*model.Article, error) {
+	var articles []*model.Article
+	if err := utils.ApplyArticleFilter(r.DB, articleFilter(ctx, nil)).
+		Joins("JOIN article_entities ON article_entities.article_id = articles.id").
+		Where("article_entities.entity_id = ?", obj.ID).
+		Order("articles.published_at DESC").
+		Find(&articles).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load entity articles", errStr, code, ctx)
+	}
+
+	return articles, nil
+}
+
+// ID returns the global ID of a keyword.
+func (r *keyWordsResolver) ID(ctx context.Context, obj *model.KeyWords) (string, error) {
+	return utils.GlobalID(utils.NodeKeyWords, obj.ID), nil
+}
+
+// History returns the hourly article counts of a keyword for sparklines.
+func (r *keyWordsResolver) History(ctx context.Context, obj *model.KeyWords, window *model.TrendWindow) ([]*model.KeywordTrendPoint, error) {
+	return r.keywordHistory(ctx, obj.ID, window)
+}
+
+// RecordView counts an opened article once per client within the dedup
+// window. The count is written to the database by the view flush worker.
+func (r *mutationResolver) RecordView(ctx context.Context, articleID string) (bool, error) {
+	articleID = utils.LocalID(utils.NodeArticle, articleID)
+
+	var article model.Article
+	if err := r.DB.Select("id").Where("id = ?", articleID).First(&article).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return false, utils.GqlError("Failed to record view", errStr, code, ctx)
+	}
+
+	return utils.Views.RecordOpen(ctx, GetClientFromContext(ctx), article.ID), nil
+}
+
+// LinkSimilarArticles stores the trigram matches of an article as links.
+func (r *mutationResolver) LinkSimilarArticles(ctx context.Context, id string, amount *int32) ([]*model.ScoredArticle, error) {
+	id = utils.LocalID(utils.NodeArticle, id)
+
+	var article model.Article
+	if err := r.DB.First(&article, "id = ?", id).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load article", errStr, code, ctx)
+	}
+
+	scored, err := utils.LinkSimilarArticles(r.DB, article, similarLimit(amount))
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to link similar articles", errStr, code, ctx)
+	}
+	return scored, nil
+}
+
+// AddKeywordAlias maps a keyword variant onto a canonical keyword. It is also
+// how suggested aliases are accepted.
+func (r *mutationResolver) AddKeywordAlias(ctx context.Context, alias string, canonical string) (*model.KeywordAlias, error) {
+	result, err := utils.AddKeywordAlias(r.DB, alias, canonical)
+	if err != nil {
+		return nil, keywordCurationError(ctx, "Failed to add keyword alias", err)
+	}
+	return result, nil
+}
+
+// MergeKeywords folds one keyword into another.
+func (r *mutationResolver) MergeKeywords(ctx context.Context, sourceID string, targetID string) (*model.ResponseKeyWords, error) {
+	sourceID = utils.LocalID(utils.NodeKeyWords, sourceID)
+	targetID = utils.LocalID(utils.NodeKeyWords, targetID)
+
+	kw, err := utils.MergeKeywords(r.DB, sourceID, targetID)
+	if err != nil {
+		return nil, keywordCurationError(ctx, "Failed to merge keywords", err)
+	}
+	return r.responseKeyword(ctx, kw)
+}
+
+// RenameKeyword changes the display name of a keyword.
+func (r *mutationResolver) RenameKeyword(ctx context.Context, id string, keyword string) (*model.ResponseKeyWords, error) {
+	id = utils.LocalID(utils.NodeKeyWords, id)
+
+	kw, err := utils.RenameKeyword(r.DB, id, keyword)
+	if err != nil {
+		return nil, keywordCurationError(ctx, "Failed to rename keyword", err)
+	}
+	return r.responseKeyword(ctx, kw)
+}
+
+// BlockKeyword hides a keyword and stops the generator from producing it.
+func (r *mutationResolver) BlockKeyword(ctx context.Context, id string, blocked *bool) (*model.ResponseKeyWords, error) {
+	id = utils.LocalID(utils.NodeKeyWords, id)
+
+	kw, err := utils.SetKeywordBlocked(r.DB, id, blocked == nil || *blocked)
+	if err != nil {
+		return nil, keywordCurationError(ctx, "Failed to block keyword", err)
+	}
+	return r.responseKeyword(ctx, kw)
+}
+
+// PinKeyword keeps a keyword listed first until it is unpinned.
+func (r *mutationResolver) PinKeyword(ctx context.Context, id string, pinned *bool) (*model.ResponseKeyWords, error) {
+	id = utils.LocalID(utils.NodeKeyWords, id)
+
+	kw, err := utils.SetKeywordPinned(r.DB, id, pinned == nil || *pinned)
+	if err != nil {
+		return nil, keywordCurationError(ctx, "Failed to pin keyword", err)
+	}
+	return r.responseKeyword(ctx, kw)
+}
+
+// AddWordListEntries adds stopwords or blocked terms, effective from the next
+// keyword run.
+func (r *mutationResolver) AddWordListEntries(ctx context.Context, kind model.WordListKind, language model.Language, words []string) (*model.WordList, error) {
+	list, err := utils.AddWordListEntries(r.DB, kind, language, words)
+	if errors.Is(err, utils.ErrInvalidWordList) {
+		return nil, utils.GqlError("Failed to add word list entries", "expected a known language and at least one single word", 400, ctx)
+	}
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to add word list entries", errStr, code, ctx)
+	}
+	return list, nil
+}
+
+// Node refetches any object by its global ID.
+func (r *queryResolver) Node(ctx context.Context, id string) (model.Node, error) {
+	nodes, err := utils.LoadNodes(r.DB, []string{id}, relationFilter(ctx))
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load node", errStr, code, ctx)
+	}
+	return nodes[0], nil
+}
+
+// Nodes refetches objects by their global IDs, one query per type.
+func (r *queryResolver) Nodes(ctx context.Context, ids []string) ([]model.Node, error) {
+	nodes, err := utils.LoadNodes(r.DB, ids, relationFilter(ctx))
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load nodes", errStr, code, ctx)
+	}
+	return nodes, nil
+}
+
 // Articles returns all articles, optionally cached.
-func (r *queryResolver) Articles(ctx context.Context) ([]*model.Article, error) {
+func (r *queryResolver) Articles(ctx context.Context, filter *model.ArticleFilter) ([]*model.Article, error) {
 	cache.SetHint(ctx, cache.ScopePublic, 15*time.Minute)
 
-	lang := GetLanguageFromContext(ctx)
-
 	var articles []*model.Article
-	if err := r.DB.Preload("LinkedTo").Preload("LinkedFrom").Preload("Keywords").
-		Where("language = ?", lang).
+	if err := utils.ApplyArticleFilter(r.DB, articleFilter(ctx, filter)).
 		Find(&articles).Error; err != nil {
 		errStr, code := utils.HandleGormError(err)
 		return nil, &gqlerror.Error{
@@ -39,17 +252,18 @@ func (r *queryResolver) Articles(ctx context.Context) ([]*model.Article, error)
 	return articles, nil
 }
 
-// TopArticles returns articles ordered by views.
-func (r *queryResolver) TopArticles(ctx context.Context, amount int32) ([]*model.Article, error) {
+// TopArticles returns the first articles of a sort order, trending by default.
+func (r *queryResolver) TopArticles(ctx context.Context, amount int32, filter *model.ArticleFilter, sort *model.ArticleSort) ([]*model.Article, error) {
 	cache.SetHint(ctx, cache.ScopePublic, 1*time.Minute)
 
-	lang := GetLanguageFromContext(ctx)
+	order := utils.OrderTrending
+	if sort != nil {
+		order = utils.ArticleSortOrder(*sort)
+	}
 
 	var articles []*model.Article
-	if err := r.DB.Preload("LinkedTo").Preload("LinkedFrom").
-		Where("language = ?", lang).
-		Order("views DESC").
-		Limit(int(amount)).
+	if err := order.Order(utils.ApplyArticleFilter(r.DB, articleFilter(ctx, filter))).
+		Limit(utils.ListSize(amount)).
 		Find(&articles).Error; err != nil {
 		errStr, code := utils.HandleGormError(err)
 		return nil, &gqlerror.Error{
@@ -61,15 +275,139 @@ func (r *queryResolver) TopArticles(ctx context.Context, amount int32) ([]*model
 		}
 	}
 
-	r.UpdateViews(ctx, articles)
+	utils.Views.RecordImpressions(ctx, articles)
 	return articles, nil
 }
 
-// LinkedArticles returns articles linked to a given article.
-func (r *queryResolver) LinkedArticles(ctx context.Context, id string) ([]*model.Article, error) {
+// RecentArticles pages through articles newest first.
+func (r *queryResolver) RecentArticles(ctx context.Context, first *int32, after *string, filter *model.ArticleFilter) (*model.ArticleConnection, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	query := utils.ApplyArticleFilter(r.DB, articleFilter(ctx, filter))
+	return r.articleConnection(ctx, query, utils.OrderRecent, first, after)
+}
+
+// PopularArticles pages through articles by views, most viewed first, or by
+// another sort order.
+func (r *queryResolver) PopularArticles(ctx context.Context, first *int32, after *string, filter *model.ArticleFilter, sort *model.ArticleSort) (*model.ArticleConnection, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 1*time.Minute)
+
+	order := utils.OrderViews
+	if sort != nil {
+		order = utils.ArticleSortOrder(*sort)
+	}
+
+	query := utils.ApplyArticleFilter(r.DB, articleFilter(ctx, filter))
+	return r.articleConnection(ctx, query, order, first, after)
+}
+
+// KeywordArticles pages through the articles of a keyword newest first.
+func (r *queryResolver) KeywordArticles(ctx context.Context, id string, first *int32, after *string, filter *model.ArticleFilter) (*model.ArticleConnection, error) {
+	id = utils.LocalID(utils.NodeKeyWords, id)
+
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	query := r.DB.
+		Joins("JOIN article_keywords ak ON ak.article_id = articles.id AND ak.key_words_id = ?", id)
+	query = utils.ApplyArticleFilter(query, articleFilter(ctx, filter))
+	return r.articleConnection(ctx, query, utils.OrderRecent, first, after)
+}
+
+// Search pages through articles matching a full-text query, best match first.
+func (r *queryResolver) Search(ctx context.Context, query string, language *model.Language, sources []model.Source, from *time.Time, to *time.Time, first *int32, after *string, filter *model.ArticleFilter) (*model.SearchConnection, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	// Explicit arguments take precedence over the filter
+	var f model.ArticleFilter
+	if filter != nil {
+		f = *filter
+	}
+	if language != nil {
+		f.Language = language
+	}
+	if len(sources) > 0 {
+		f.Sources = sources
+	}
+	if from != nil {
+		f.From = from
+	}
+	if to != nil {
+		f.To = to
+	}
+
+	hits, hasNext, err := utils.SearchArticles(r.DB, query, articleFilter(ctx, &f), first, after)
+	if errors.Is(err, utils.ErrEmptySearchQuery) || errors.Is(err, utils.ErrInvalidCursor) {
+		return nil, utils.GqlError("Failed to search articles", err.Error(), 400, ctx)
+	}
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to search articles", errStr, code, ctx)
+	}
+
+	conn := &model.SearchConnection{
+		Edges: make([]*model.SearchEdge, 0, len(hits)),
+		PageInfo: &model.PageInfo{
+			HasNextPage:     hasNext,
+			HasPreviousPage: after != nil && *after != "",
+		},
+	}
+	if len(hits) == 0 {
+		return conn, nil
+	}
+
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var articles []*model.Article
+	if err := r.DB.
+		Where("id IN ?", ids).
+		Find(&articles).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load search results", errStr, code, ctx)
+	}
+
+	byID := make(map[string]*model.Article, len(articles))
+	for _, a := range articles {
+		byID[a.ID] = a
+	}
+
+	page := make([]*model.Article, 0, len(hits))
+	for _, hit := range hits {
+		article, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		conn.Edges = append(conn.Edges, &model.SearchEdge{
+			Cursor:   hit.Cursor().Encode(),
+			Node:     article,
+			Rank:     hit.Rank,
+			Headline: hit.Headline,
+		})
+		page = append(page, article)
+	}
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+
+	utils.Views.RecordImpressions(ctx, page)
+	return conn, nil
+}
+
+// LinkedArticles returns articles linked to a given article. With crossLanguage
+// set, links to articles in other languages are included as well.
+func (r *queryResolver) LinkedArticles(ctx context.Context, id string, crossLanguage *bool, filter *model.ArticleFilter) ([]*model.Article, error) {
+	id = utils.LocalID(utils.NodeArticle, id)
+
 	cache.SetHint(ctx, cache.ScopePublic, 15*time.Minute)
 
-	lang := GetLanguageFromContext(ctx)
+	// crossLanguage drops the Accept-Language default, not an explicit language
+	f := articleFilter(ctx, filter)
+	if crossLanguage != nil && *crossLanguage && (filter == nil || filter.Language == nil) {
+		f.Language = nil
+	}
 
 	// Load the article with LinkedTo relation
 	var article model.Article
@@ -78,82 +416,81 @@ func (r *queryResolver) LinkedArticles(ctx context.Context, id string) ([]*model
 		return nil, utils.GqlError("Failed to load article", errStr, code, ctx)
 	}
 
-	// If LinkedTo exists, filter by language and return
+	// If LinkedTo exists, filter and return
 	if len(article.LinkedTo) > 0 {
 		var filteredLinked []*model.Article
 		for _, linked := range article.LinkedTo {
-			if linked.Language == lang {
+			if utils.MatchesArticleFilter(linked, f) {
 				filteredLinked = append(filteredLinked, linked)
 			}
 		}
 		return filteredLinked, nil
 	}
 
-	// Otherwise, compute similarity (optional, your trigram query)
-	tx := r.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			panic(r)
+	// Otherwise, return trigram matches in the article's language without
+	// storing them; links are only written by the cron and admins
+	scored, err := utils.FindSimilarArticles(r.DB, article, similarArticlesLimit)
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Similarity query failed", errStr, code, ctx)
+	}
+
+	similar := make([]*model.Article, 0, len(scored))
+	for _, s := range scored {
+		if utils.MatchesArticleFilter(s.Article, f) {
+			similar = append(similar, s.Article)
 		}
-	}()
+	}
+	return similar, nil
+}
+
+// SimilarArticles returns trigram matches in the article's language with their
+// scores. Nothing is stored; see the linkSimilarArticles mutation.
+func (r *queryResolver) SimilarArticles(ctx context.Context, id string, amount *int32, filter *model.ArticleFilter) ([]*model.ScoredArticle, error) {
+	id = utils.LocalID(utils.NodeArticle, id)
+
+	cache.SetHint(ctx, cache.ScopePublic, 15*time.Minute)
 
-	if tx.Error != nil {
-		return nil, utils.GqlError("Failed to start transaction", tx.Error.Error(), 500, ctx)
+	// Matches share the article's language, so the Accept-Language default
+	// does not apply
+	f := articleFilter(ctx, filter)
+	if filter == nil || filter.Language == nil {
+		f.Language = nil
 	}
 
-	if err := tx.Exec("SET LOCAL pg_trgm.similarity_threshold = 0.29").Error; err != nil {
-		tx.Rollback()
+	var article model.Article
+	if err := r.DB.First(&article, "id = ?", id).Error; err != nil {
 		errStr, code := utils.HandleGormError(err)
-		return nil, utils.GqlError("Failed to set trigram threshold", errStr, code, ctx)
+		return nil, utils.GqlError("Failed to load article", errStr, code, ctx)
 	}
 
-	// Query similar articles (your existing raw SQL)
-	query := `
-		WITH source AS (
-			SELECT * FROM articles WHERE id = ?
-		)
-		SELECT
-			a2.*
-		FROM
-			source s
-		JOIN
-			articles a2 ON s.id < a2.id
-		WHERE
-			ABS(EXTRACT(EPOCH FROM (s.published_at - a2.published_at)) / 86400) <= 1
-			AND a2.language = ?
-		LIMIT 300;
-	`
-
-	var similar []*model.Article
-	if err := tx.Raw(query, id, lang).Scan(&similar).Error; err != nil {
-		tx.Rollback()
+	// Fetch the most matches so filtering still leaves enough
+	scored, err := utils.FindSimilarArticles(r.DB, article, similarArticlesLimit)
+	if err != nil {
 		errStr, code := utils.HandleGormError(err)
 		return nil, utils.GqlError("Similarity query failed", errStr, code, ctx)
 	}
 
-	// Persist links via GORM association
-	if len(similar) > 0 {
-		if err := tx.Model(&article).Association("LinkedTo").Append(similar); err != nil {
-			tx.Rollback()
-			return nil, utils.GqlError("Failed to append LinkedTo", err.Error(), 500, ctx)
+	limit := similarLimit(amount)
+	result := make([]*model.ScoredArticle, 0, limit)
+	for _, s := range scored {
+		if len(result) == limit {
+			break
+		}
+		if utils.MatchesArticleFilter(s.Article, f) {
+			result = append(result, s)
 		}
 	}
-
-	if err := tx.Commit().Error; err != nil {
-		return nil, utils.GqlError("Commit failed", err.Error(), 500, ctx)
-	}
-
-	return similar, nil
+	return result, nil
 }
 
 // Article returns a single article by ID.
 func (r *queryResolver) Article(ctx context.Context, id string) (*model.Article, error) {
-	lang := GetLanguageFromContext(ctx)
+	id = utils.LocalID(utils.NodeArticle, id)
 
 	var article model.Article
-	if err := r.DB.Preload("LinkedTo").Preload("LinkedFrom").Preload("Keywords").
-		Where("id = ? AND language = ?", id, lang).
+	if err := utils.ApplyArticleFilter(r.DB, articleFilter(ctx, nil)).
+		Where("articles.id = ?", id).
 		First(&article).Error; err != nil {
 		errStr, code := utils.HandleGormError(err)
 		return nil, &gqlerror.Error{
@@ -165,21 +502,18 @@ func (r *queryResolver) Article(ctx context.Context, id string) (*model.Article,
 		}
 	}
 
-	r.UpdateViews(ctx, []*model.Article{&article})
+	utils.Views.RecordImpressions(ctx, []*model.Article{&article})
 	return &article, nil
 }
 
 // RecentArticle returns the most recently published articles.
-func (r *queryResolver) RecentArticle(ctx context.Context, amount int32) ([]*model.Article, error) {
+func (r *queryResolver) RecentArticle(ctx context.Context, amount int32, filter *model.ArticleFilter) ([]*model.Article, error) {
 	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
 
-	lang := GetLanguageFromContext(ctx)
-
 	var articles []*model.Article
-	if err := r.DB.Preload("LinkedTo").Preload("LinkedFrom").Preload("Keywords").
-		Where("language = ?", lang).
+	if err := utils.ApplyArticleFilter(r.DB, articleFilter(ctx, filter)).
 		Order("published_at DESC").
-		Limit(int(amount)).
+		Limit(utils.ListSize(amount)).
 		Find(&articles).Error; err != nil {
 		errStr, code := utils.HandleGormError(err)
 		graphql.AddError(ctx, &gqlerror.Error{
@@ -189,24 +523,24 @@ func (r *queryResolver) RecentArticle(ctx context.Context, amount int32) ([]*mod
 		})
 	}
 
-	r.UpdateViews(ctx, articles)
+	utils.Views.RecordImpressions(ctx, articles)
 	return articles, nil
 }
 
 // NextRecentArticle is the resolver for the nextRecentArticle field.
-func (r *queryResolver) NextRecentArticle(ctx context.Context, start int32, stop int32) ([]*model.Article, error) {
+func (r *queryResolver) NextRecentArticle(ctx context.Context, start int32, stop int32, filter *model.ArticleFilter) ([]*model.Article, error) {
 	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
 
-	lang := GetLanguageFromContext(ctx)
-
 	limit := stop - start
 	if limit <= 0 {
 		return []*model.Article{}, nil
 	}
+	if limit > utils.MaxPageSize {
+		limit = utils.MaxPageSize
+	}
 
 	var articles []*model.Article
-	if err := r.DB.Preload("LinkedTo").Preload("LinkedFrom").Preload("Keywords").
-		Where("language = ?", lang).
+	if err := utils.ApplyArticleFilter(r.DB, articleFilter(ctx, filter)).
 		Order("published_at DESC").
 		Offset(int(start)).
 		Limit(int(limit)).
@@ -219,20 +553,18 @@ func (r *queryResolver) NextRecentArticle(ctx context.Context, start int32, stop
 		}
 	}
 
-	r.UpdateViews(ctx, articles)
+	utils.Views.RecordImpressions(ctx, articles)
 	return articles, nil
 }
 
 // BatchFindArticles returns multiple articles by IDs.
-func (r *queryResolver) BatchFindArticles(ctx context.Context, ids []*string) ([]*model.Article, error) {
+func (r *queryResolver) BatchFindArticles(ctx context.Context, ids []*string, filter *model.ArticleFilter) ([]*model.Article, error) {
 	cache.SetHint(ctx, cache.ScopePublic, 24*7*time.Hour)
 
-	lang := GetLanguageFromContext(ctx)
-
 	nonNilIDs := make([]string, 0, len(ids))
 	for _, id := range ids {
 		if id != nil {
-			nonNilIDs = append(nonNilIDs, *id)
+			nonNilIDs = append(nonNilIDs, utils.LocalID(utils.NodeArticle, *id))
 		}
 	}
 
@@ -241,8 +573,8 @@ func (r *queryResolver) BatchFindArticles(ctx context.Context, ids []*string) ([
 	}
 
 	var articles []*model.Article
-	if err := r.DB.Preload("LinkedTo").Preload("LinkedFrom").Preload("Keywords").
-		Where("id IN ? AND language = ?", nonNilIDs, lang).
+	if err := utils.ApplyArticleFilter(r.DB, articleFilter(ctx, filter)).
+		Where("id IN ?", nonNilIDs).
 		Find(&articles).Error; err != nil {
 		errStr, code := utils.HandleGormError(err)
 		return nil, &gqlerror.Error{
@@ -252,7 +584,7 @@ func (r *queryResolver) BatchFindArticles(ctx context.Context, ids []*string) ([
 		}
 	}
 
-	r.UpdateViews(ctx, articles)
+	utils.Views.RecordImpressions(ctx, articles)
 	return articles, nil
 }
 
@@ -261,7 +593,7 @@ func (r *queryResolver) Keywords(ctx context.Context) ([]*model.ResponseKeyWords
 	lang := GetLanguageFromContext(ctx)
 
 	var keywords []*model.KeyWords
-	if err := r.DB.Preload("Articles", "language = ?", lang).Order("last_update DESC").Find(&keywords).Error; err != nil {
+	if err := r.DB.Preload("Articles", filteredArticles(articleFilter(ctx, nil))).Where("expired_at IS NULL AND language = ?", lang).Order("pinned DESC, last_update DESC").Find(&keywords).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch keywords: %w", err)
 	}
 
@@ -281,7 +613,496 @@ func (r *queryResolver) Keywords(ctx context.Context) ([]*model.ResponseKeyWords
 	return response, nil
 }
 
+// TrendingKeywords returns the keywords whose activity in the window rose the
+// most above their trailing baseline.
+func (r *queryResolver) TrendingKeywords(ctx context.Context, window *model.TrendWindow, amount *int32) ([]*model.TrendingKeyword, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	lang := GetLanguageFromContext(ctx)
+
+	w := model.TrendWindowDay
+	if window != nil {
+		w = *window
+	}
+	limit := 10
+	if amount != nil && *amount > 0 {
+		limit = int(*amount)
+	}
+
+	velocities, err := utils.KeywordVelocities(r.DB, lang, utils.TrendWindowDuration(w), limit)
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, &gqlerror.Error{
+			Path:       graphql.GetPath(ctx),
+			Message:    fmt.Sprintf("Failed to compute trending keywords: %s", errStr),
+			Extensions: map[string]any{"code": code},
+		}
+	}
+	if len(velocities) == 0 {
+		return []*model.TrendingKeyword{}, nil
+	}
+
+	ids := make([]string, len(velocities))
+	for i, v := range velocities {
+		ids[i] = v.KeyWordsID
+	}
+
+	var keywords []*model.KeyWords
+	if err := r.DB.Preload("Articles", filteredArticles(articleFilter(ctx, nil))).
+		Where("id IN ?", ids).
+		Find(&keywords).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load keywords", errStr, code, ctx)
+	}
+
+	byID := make(map[string]*model.KeyWords, len(keywords))
+	for _, kw := range keywords {
+		byID[kw.ID] = kw
+	}
+
+	trending := make([]*model.TrendingKeyword, 0, len(velocities))
+	for _, v := range velocities {
+		kw, ok := byID[v.KeyWordsID]
+		if !ok || len(kw.Articles) == 0 {
+			continue
+		}
+		trending = append(trending, &model.TrendingKeyword{
+			Keyword: &model.ResponseKeyWords{
+				ID:         kw.ID,
+				Keyword:    kw.Keyword,
+				LastUpdate: kw.LastUpdate,
+				Articles:   kw.Articles,
+			},
+			ArticleCount: int32(v.ArticleCount),
+			Velocity:     v.ZScore,
+			Acceleration: int32(v.Acceleration),
+		})
+	}
+
+	return trending, nil
+}
+
+// RelatedKeywords returns the keywords most strongly co-occurring with a
+// keyword so readers can move between topics.
+func (r *queryResolver) RelatedKeywords(ctx context.Context, id string, limit *int32) ([]*model.RelatedKeyword, error) {
+	id = utils.LocalID(utils.NodeKeyWords, id)
+
+	cache.SetHint(ctx, cache.ScopePublic, 15*time.Minute)
+
+	lang := GetLanguageFromContext(ctx)
+
+	n := 10
+	if limit != nil && *limit > 0 {
+		n = int(*limit)
+	}
+
+	var edges []model.KeywordEdge
+	if err := r.DB.Where("key_words_id = ?", id).
+		Order("weight DESC, shared_articles DESC").
+		Limit(n).
+		Find(&edges).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, &gqlerror.Error{
+			Path:       graphql.GetPath(ctx),
+			Message:    fmt.Sprintf("Failed to fetch related keywords for %s: %s", id, errStr),
+			Extensions: map[string]any{"code": code},
+		}
+	}
+	if len(edges) == 0 {
+		return []*model.RelatedKeyword{}, nil
+	}
+
+	ids := make([]string, len(edges))
+	for i, e := range edges {
+		ids[i] = e.RelatedID
+	}
+
+	var keywords []*model.KeyWords
+	if err := r.DB.Preload("Articles", filteredArticles(articleFilter(ctx, nil))).
+		Where("id IN ? AND expired_at IS NULL AND language = ?", ids, lang).
+		Find(&keywords).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load keywords", errStr, code, ctx)
+	}
+
+	byID := make(map[string]*model.KeyWords, len(keywords))
+	for _, kw := range keywords {
+		byID[kw.ID] = kw
+	}
+
+	related := make([]*model.RelatedKeyword, 0, len(edges))
+	for _, e := range edges {
+		kw, ok := byID[e.RelatedID]
+		if !ok || len(kw.Articles) == 0 {
+			continue
+		}
+		related = append(related, &model.RelatedKeyword{
+			Keyword: &model.ResponseKeyWords{
+				ID:         kw.ID,
+				Keyword:    kw.Keyword,
+				LastUpdate: kw.LastUpdate,
+				Articles:   kw.Articles,
+			},
+			SharedArticles: e.SharedArticles,
+			Weight:         e.Weight,
+		})
+	}
+
+	return related, nil
+}
+
+// Stories returns the most recently updated stories.
+func (r *queryResolver) Stories(ctx context.Context, amount int32, filter *model.ArticleFilter) ([]*model.Story, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	matching := utils.ApplyArticleFilter(r.DB.Model(&model.Article{}), articleFilter(ctx, filter)).
+		Select("1").
+		Where("articles.story_id = stories.id")
+
+	var stories []*model.Story
+	if err := r.DB.Where("EXISTS (?)", matching).
+		Order("last_updated DESC").
+		Limit(utils.ListSize(amount)).
+		Find(&stories).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, &gqlerror.Error{
+			Path:       graphql.GetPath(ctx),
+			Message:    fmt.Sprintf("Failed to fetch stories: %s", errStr),
+			Extensions: map[string]any{"code": code},
+		}
+	}
+
+	return stories, nil
+}
+
+// Story returns a single story by ID.
+func (r *queryResolver) Story(ctx context.Context, id string) (*model.Story, error) {
+	id = utils.LocalID(utils.NodeStory, id)
+
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	var story model.Story
+	if err := r.DB.Where("id = ?", id).First(&story).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, &gqlerror.Error{
+			Path:       graphql.GetPath(ctx),
+			Message:    fmt.Sprintf("Failed to fetch story with ID %s: %s", id, errStr),
+			Extensions: map[string]any{"code": code},
+		}
+	}
+
+	return &story, nil
+}
+
+// StoryCoverage compares how each source covered a story.
+func (r *queryResolver) StoryCoverage(ctx context.Context, id string) (*model.StoryCoverage, error) {
+	id = utils.LocalID(utils.NodeStory, id)
+
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	var story model.Story
+	if err := r.DB.Where("id = ?", id).First(&story).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, &gqlerror.Error{
+			Path:       graphql.GetPath(ctx),
+			Message:    fmt.Sprintf("Failed to fetch story with ID %s: %s", id, errStr),
+			Extensions: map[string]any{"code": code},
+		}
+	}
+
+	var articles []*model.Article
+	if err := utils.ApplyArticleFilter(r.DB, relationFilter(ctx)).
+		Where("articles.story_id = ?", story.ID).
+		Order("published_at ASC").
+		Find(&articles).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load story articles", errStr, code, ctx)
+	}
+
+	return utils.BuildStoryCoverage(&story, articles), nil
+}
+
+// Blindspots returns stories covered predominantly by one side of the spectrum.
+// Without since, stories updated in the last 48 hours are considered.
+func (r *queryResolver) Blindspots(ctx context.Context, since *time.Time) ([]*model.Blindspot, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 15*time.Minute)
+
+	cutoff := time.Now().Add(-48 * time.Hour)
+	if since != nil {
+		cutoff = *since
+	}
+
+	var stories []*model.Story
+	if err := r.DB.Where("last_updated >= ?", cutoff).
+		Order("last_updated DESC").
+		Find(&stories).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, &gqlerror.Error{
+			Path:       graphql.GetPath(ctx),
+			Message:    fmt.Sprintf("Failed to fetch stories: %s", errStr),
+			Extensions: map[string]any{"code": code},
+		}
+	}
+
+	return utils.FindBlindspots(stories, utils.DefaultBlindspotConfig()), nil
+}
+
+// Entity looks up a named entity case-insensitively.
+func (r *queryResolver) Entity(ctx context.Context, name string, typeArg *model.EntityType) (*model.Entity, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 15*time.Minute)
+
+	query := r.DB.Where("LOWER(name) = LOWER(?)", name)
+	if typeArg != nil {
+		query = query.Where("type = ?", *typeArg)
+	}
+
+	// A name such as "Paris" can be tagged as several types; prefer the one
+	// most articles mention
+	var entity model.Entity
+	if err := query.
+		Order("(SELECT COUNT(*) FROM article_entities ae WHERE ae.entity_id = entities.id) DESC, id").
+		First(&entity).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, &gqlerror.Error{
+			Path:       graphql.GetPath(ctx),
+			Message:    fmt.Sprintf("Failed to fetch entity %s: %s", name, errStr),
+			Extensions: map[string]any{"code": code},
+		}
+	}
+
+	return &entity, nil
+}
+
+// Sources returns every scraped outlet with its metadata and statistics.
+func (r *queryResolver) Sources(ctx context.Context) ([]*model.SourceInfo, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	infos, err := utils.SourceInfos(r.DB, scrapper.Sources())
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load sources", errStr, code, ctx)
+	}
+	return infos, nil
+}
+
+// Source returns a single outlet, or null when it is not scraped.
+func (r *queryResolver) Source(ctx context.Context, name model.Source) (*model.SourceInfo, error) {
+	cache.SetHint(ctx, cache.ScopePublic, 5*time.Minute)
+
+	if !slices.Contains(scrapper.Sources(), name) {
+		return nil, nil
+	}
+
+	infos, err := utils.SourceInfos(r.DB, []model.Source{name})
+	if err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load source", errStr, code, ctx)
+	}
+	return infos[0], nil
+}
+
+// ExplainLink breaks down the similarity score of two articles so editors can
+// see why they were, or were not, linked.
+func (r *queryResolver) ExplainLink(ctx context.Context, a string, b string) (*model.LinkExplanation, error) {
+	a = utils.LocalID(utils.NodeArticle, a)
+	b = utils.LocalID(utils.NodeArticle, b)
+
+	var articles []*model.Article
+	if err := r.DB.Where("id IN ?", []string{a, b}).Find(&articles).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load articles", errStr, code, ctx)
+	}
+
+	var articleA, articleB *model.Article
+	for _, article := range articles {
+		if article.ID == a {
+			articleA = article
+		}
+		if article.ID == b {
+			articleB = article
+		}
+	}
+	if articleA == nil || articleB == nil {
+		return nil, utils.GqlError("Failed to load articles", "Record not found", 404, ctx)
+	}
+
+	var links int64
+	if err := r.DB.Table("article_links").
+		Where("(article_id = ? AND linked_article_id = ?) OR (article_id = ? AND linked_article_id = ?)", a, b, b, a).
+		Count(&links).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load article links", errStr, code, ctx)
+	}
+
+	explanation := utils.ExplainSimilarity(*articleA, *articleB, utils.DefaultSimilarityConfig())
+
+	return &model.LinkExplanation{
+		ArticleA:         articleA,
+		ArticleB:         articleB,
+		Score:            explanation.Score,
+		Threshold:        utils.LinkThresholdFor(*articleA, *articleB),
+		Linked:           links > 0,
+		TitleScore:       explanation.Scores.Title,
+		DescriptionScore: explanation.Scores.Description,
+		TimeScore:        explanation.Scores.Time,
+		TimeBucket:       explanation.TimeBucket,
+		SourceScore:      explanation.Scores.Source,
+		SameSource:       explanation.SameSource,
+		SharedTokens:     explanation.SharedTokens,
+	}, nil
+}
+
+// KeywordAliases lists active aliases and pending suggestions for review.
+func (r *queryResolver) KeywordAliases(ctx context.Context, status *model.KeywordAliasStatus) ([]*model.KeywordAlias, error) {
+	query := r.DB.Order("score DESC, alias ASC")
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+
+	var aliases []*model.KeywordAlias
+	if err := query.Find(&aliases).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to fetch keyword aliases", errStr, code, ctx)
+	}
+
+	return aliases, nil
+}
+
+// WordList returns the active words of a stopword list or blocklist.
+func (r *queryResolver) WordList(ctx context.Context, kind model.WordListKind, language model.Language) (*model.WordList, error) {
+	return utils.WordList(kind, language), nil
+}
+
+// ID returns the global ID of a keyword, shared with KeyWords.
+func (r *responseKeyWordsResolver) ID(ctx context.Context, obj *model.ResponseKeyWords) (string, error) {
+	return utils.GlobalID(utils.NodeKeyWords, obj.ID), nil
+}
+
+// History returns the hourly article counts of a keyword for sparklines.
+func (r *responseKeyWordsResolver) History(ctx context.Context, obj *model.ResponseKeyWords, window *model.TrendWindow) ([]*model.KeywordTrendPoint, error) {
+	return r.keywordHistory(ctx, obj.ID, window)
+}
+
+// ID returns the global ID of a story.
+func (r *storyResolver) ID(ctx context.Context, obj *model.Story) (string, error) {
+	return utils.GlobalID(utils.NodeStory, obj.ID), nil
+}
+
+// Sources returns the outlets that covered the story.
+func (r *storyResolver) Sources(ctx context.Context, obj *model.Story) ([]model.Source, error) {
+	sources := make([]model.Source, 0, len(obj.Sources))
+	for _, s := range obj.Sources {
+		sources = append(sources, model.Source(s))
+	}
+	return sources, nil
+}
+
+// Articles returns the member articles of a story, oldest first.
+func (r *storyResolver) Articles(ctx context.Context, obj *model.Story) ([]*model.Article, error) {
+	var articles []*model.Article
+	if err := utils.ApplyArticleFilter(r.DB, relationFilter(ctx)).
+		Where("articles.story_id = ?", obj.ID).
+		Order("published_at ASC").
+		Find(&articles).Error; err != nil {
+		errStr, code := utils.HandleGormError(err)
+		return nil, utils.GqlError("Failed to load story articles", errStr, code, ctx)
+	}
+
+	return articles, nil
+}
+
+// ArticleAdded streams newly scraped articles matching the filter.
+func (r *subscriptionResolver) ArticleAdded(ctx context.Context, filter *model.ArticleFilter) (<-chan *model.Article, error) {
+	f := articleFilter(ctx, filter)
+	events := utils.Events.Subscribe(ctx, utils.TopicArticleAdded)
+
+	out := make(chan *model.Article, 1)
+	go func() {
+		defer close(out)
+		for payload := range events {
+			var articles []*model.Article
+			if err := json.Unmarshal(payload, &articles); err != nil {
+				utils.Log(utils.GraphQL, "Ignoring malformed article event", "error", err)
+				continue
+			}
+			for _, a := range articles {
+				if !utils.MatchesArticleFilter(a, f) {
+					continue
+				}
+				select {
+				case out <- a:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// StoryUpdated streams a story each time it changes.
+func (r *subscriptionResolver) StoryUpdated(ctx context.Context, id string) (<-chan *model.Story, error) {
+	id = utils.LocalID(utils.NodeStory, id)
+
+	events := utils.Events.Subscribe(ctx, utils.TopicStoryUpdated)
+
+	out := make(chan *model.Story, 1)
+	go func() {
+		defer close(out)
+		for payload := range events {
+			var ids []string
+			if err := json.Unmarshal(payload, &ids); err != nil {
+				utils.Log(utils.GraphQL, "Ignoring malformed story event", "error", err)
+				continue
+			}
+			if !slices.Contains(ids, id) {
+				continue
+			}
+
+			var story model.Story
+			if err := r.DB.WithContext(ctx).Where("id = ?", id).First(&story).Error; err != nil {
+				utils.Log(utils.GraphQL, "Failed to load updated story", "id", id, "error", err)
+				continue
+			}
+			select {
+			case out <- &story:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Article returns ArticleResolver implementation.
+func (r *Resolver) Article() ArticleResolver { return &articleResolver{r} }
+
+// Entity returns EntityResolver implementation.
+func (r *Resolver) Entity() EntityResolver { return &entityResolver{r} }
+
+// KeyWords returns KeyWordsResolver implementation.
+func (r *Resolver) KeyWords() KeyWordsResolver { return &keyWordsResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// ResponseKeyWords returns ResponseKeyWordsResolver implementation.
+func (r *Resolver) ResponseKeyWords() ResponseKeyWordsResolver { return &responseKeyWordsResolver{r} }
+
+// Story returns StoryResolver implementation.
+func (r *Resolver) Story() StoryResolver { return &storyResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type articleResolver struct{ *Resolver }
+type entityResolver struct{ *Resolver }
+type keyWordsResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type responseKeyWordsResolver struct{ *Resolver }
+type storyResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"news-swipe/backend/utils"
+	"strings"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
@@ -13,6 +14,7 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
 	"github.com/landrade/gqlgen-cache-control-plugin/cache"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -20,20 +22,35 @@ import (
 )
 
 func InitGraphQL(ctx context.Context, port string, db *gorm.DB) error {
+	loadQueryLimitConfig()
+
 	resolver := &Resolver{DB: db}
 	c := Config{Resolvers: resolver}
+	c.Directives.HasRole = HasRoleDirective
+	setComplexity(&c)
 
 	srv := handler.New(NewExecutableSchema(c))
 
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			// Native clients send no Origin; browsers are limited by CORS on
+			// the regular transports already
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	})
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
 
 	srv.Use(cache.Extension{})
+	srv.AroundResponses(LoadersResponseMiddleware(db))
 
 	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
 
 	srv.Use(extension.Introspection{})
+	srv.Use(DepthLimit{MaxDepth: queryLimitConfig.MaxDepth})
+	srv.Use(complexityLimit())
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
@@ -42,11 +59,13 @@ func InitGraphQL(ctx context.Context, port string, db *gorm.DB) error {
 	router := chi.NewRouter()
 	router.Use(LanguageMiddleware)
 	router.Use(FilterMiddleware)
+	router.Use(ClientMiddleware)
+	router.Use(AuthMiddleware)
 	router.Use(RateLimitMiddleware)
 	router.Use(RedisCacheMiddleware)
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	router.Handle("/query", cache.Middleware(srv))
+	router.Handle("/query", withoutCacheControlForWebsockets(srv))
 	http.Handle("/query", router)
 	http.Handle("/metrics", promhttp.Handler())
 
@@ -69,3 +88,21 @@ func InitGraphQL(ctx context.Context, port string, db *gorm.DB) error {
 	utils.Log(utils.Server, "GraphQL server starting", "port", port, "playground", "http://localhost:"+port+"/")
 	return server.ListenAndServe()
 }
+
+// withoutCacheControlForWebsockets adds cache-control headers to regular
+// requests. Websocket upgrades bypass it since its response writer cannot be
+// hijacked.
+func withoutCacheControlForWebsockets(srv http.Handler) http.Handler {
+	cached := cache.Middleware(srv)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isWebsocketUpgrade(r) {
+			srv.ServeHTTP(w, r)
+			return
+		}
+		cached.ServeHTTP(w, r)
+	})
+}
+
+func isWebsocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
@@ -0,0 +1,39 @@
+package scrapper
+
+import (
+	"news-swipe/backend/graph/model"
+	"news-swipe/backend/scrapper/faz"
+	"news-swipe/backend/scrapper/handelsblatt"
+	"news-swipe/backend/scrapper/sueddeutsche"
+	"news-swipe/backend/scrapper/tagesschau"
+	"news-swipe/backend/scrapper/taz"
+	"news-swipe/backend/scrapper/welt"
+	"news-swipe/backend/scrapper/zeit"
+)
+
+// Scraper fetches the current articles of one source
+type Scraper struct {
+	Source model.Source
+	Name   string
+	Scrape func() ([]model.Article, error)
+}
+
+// Scrapers is the registry of all sources that are scraped
+var Scrapers = []Scraper{
+	{model.SourceDieZeit, "Zeit", zeit.Scrape},
+	{model.SourceFaz, "FAZ", faz.Scrape},
+	{model.SourceTagesschau, "Tagesschau", tagesschau.Scrape},
+	{model.SourceSueddeutsche, "Süddeutsche", sueddeutsche.Scrape},
+	{model.SourceWelt, "Welt", welt.Scrape},
+	{model.SourceHandelsblatt, "Handelsblatt", handelsblatt.Scrape},
+	{model.SourceTaz, "TAZ", taz.Scrape},
+}
+
+// Sources returns the sources with a registered scraper
+func Sources() []model.Source {
+	sources := make([]model.Source, len(Scrapers))
+	for i, s := range Scrapers {
+		sources[i] = s.Source
+	}
+	return sources
+}
@@ -57,11 +57,6 @@ func parseRSStoArticles(rss RSS) ([]model.Article, error) {
 	seenGUIDs := make(map[string]bool) // Track GUIDs to avoid duplicates
 
 	for _, item := range rss.Channel.Items {
-
-		if item.Premium == "true" {
-			continue
-		}
-
 		// Skip duplicate items based on GUID
 		if seenGUIDs[item.GUID] {
 			continue
@@ -116,6 +111,7 @@ func parseRSStoArticles(rss RSS) ([]model.Article, error) {
 			Banner:      banner,
 			Category:    categories,
 			Language:    model.FromLingua(lingua.German),
+			Paywalled:   item.Premium == "true",
 		}
 
 		articles = append(articles, article)
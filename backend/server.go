@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"news-swipe/backend/cron"
 	"news-swipe/backend/graph"
-	"news-swipe/backend/graph/model"
 	"news-swipe/backend/utils"
 	"os"
 	"os/signal"
@@ -64,7 +63,14 @@ func main() {
 		log.Fatal(err)
 	}
 
-	db.AutoMigrate(&model.Article{}, &model.KeyWords{})
+	if err := utils.Migrate(db); err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := utils.ReloadWordLists(db); err != nil {
+		utils.Log(utils.System, "Failed to load word lists", "error", err)
+	}
+	go utils.WatchWordLists(ctx, db, utils.WordListReloadInterval)
 
 	// Initialize Redis
 	if err := utils.InitRedis(); err != nil {
@@ -73,6 +79,17 @@ func main() {
 	}
 	defer utils.CloseRedis()
 
+	// Relay subscription events between replicas
+	go utils.Events.Listen(ctx)
+
+	// Shutdown waits for the final flush of buffered view counts
+	utils.Views = utils.NewViewCounter(utils.ViewDedupWindow())
+	viewsFlushed := make(chan struct{})
+	go func() {
+		defer close(viewsFlushed)
+		utils.Views.Run(ctx, db, utils.ViewFlushInterval)
+	}()
+
 	go cron.CreateCron(ctx, db)
 
 	go func() {
@@ -89,6 +106,7 @@ func main() {
 	utils.Log(utils.System, "Initiating shutdown")
 	cancel()
 
+	<-viewsFlushed
 	time.Sleep(time.Second)
 	utils.Log(utils.System, "Program exited")
 }
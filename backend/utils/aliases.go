@@ -0,0 +1,455 @@
+package utils
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// AliasSuggestionThreshold is the minimum combined string and
+	// co-occurrence score for two keywords to be suggested as aliases
+	AliasSuggestionThreshold = 0.7
+	// maxAliasHops bounds alias chains so a cycle cannot loop forever
+	maxAliasHops = 5
+)
+
+var (
+	ErrInvalidKeyword   = errors.New("keyword must not be empty")
+	ErrSameKeyword      = errors.New("alias and canonical keyword are the same")
+	ErrKeywordConflict  = errors.New("a keyword with this name already exists, merge it instead")
+	ErrLanguageMismatch = errors.New("keywords of different languages cannot be merged")
+)
+
+// keywordCuration holds the editorial decisions the generator applies on each run
+type keywordCuration struct {
+	// aliases maps a normalized variant to its normalized canonical keyword
+	aliases map[string]string
+	blocked map[string]bool
+}
+
+func loadKeywordCuration(tx *gorm.DB, lang model.Language) (*keywordCuration, error) {
+	c := &keywordCuration{
+		aliases: make(map[string]string),
+		blocked: make(map[string]bool),
+	}
+
+	var aliases []model.KeywordAlias
+	if err := tx.Where("status = ?", model.KeywordAliasStatusActive).Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range aliases {
+		c.aliases[a.Alias] = a.Canonical
+	}
+
+	var blocked []string
+	if err := tx.Model(&model.KeyWords{}).
+		Where("blocked = ? AND language = ? AND normalized IS NOT NULL", true, lang).
+		Pluck("normalized", &blocked).Error; err != nil {
+		return nil, err
+	}
+	for _, n := range blocked {
+		c.blocked[n] = true
+	}
+
+	return c, nil
+}
+
+// canonical follows the alias chain of a normalized keyword
+func (c *keywordCuration) canonical(normalized string) string {
+	for i := 0; i < maxAliasHops; i++ {
+		next, ok := c.aliases[normalized]
+		if !ok || next == normalized {
+			break
+		}
+		normalized = next
+	}
+	return normalized
+}
+
+// AddKeywordAlias maps a variant onto a canonical keyword. In every language
+// where both exist as keywords, the variant's articles are merged into the
+// canonical keyword.
+func AddKeywordAlias(db *gorm.DB, alias, canonical string) (*model.KeywordAlias, error) {
+	a, c := normalizeKeyword(alias), normalizeKeyword(canonical)
+	if a == "" || c == "" {
+		return nil, ErrInvalidKeyword
+	}
+	if a == c {
+		return nil, ErrSameKeyword
+	}
+
+	result := &model.KeywordAlias{Alias: a, Canonical: c, Score: 1, Status: model.KeywordAliasStatusActive}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := saveAliasInTx(tx, a, c); err != nil {
+			return err
+		}
+
+		var sources []model.KeyWords
+		if err := tx.Where("normalized = ?", a).Find(&sources).Error; err != nil {
+			return err
+		}
+		for i := range sources {
+			var target model.KeyWords
+			if err := tx.Where("normalized = ? AND language = ?", c, sources[i].Language).First(&target).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+			if err := moveKeywordInTx(tx, &sources[i], &target); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MergeKeywords folds the source keyword into the target. The source becomes
+// an alias of the target so later runs keep them together.
+func MergeKeywords(db *gorm.DB, sourceID, targetID string) (*model.KeyWords, error) {
+	if sourceID == targetID {
+		return nil, ErrSameKeyword
+	}
+
+	var target model.KeyWords
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var source model.KeyWords
+		if err := tx.Where("id = ?", sourceID).First(&source).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", targetID).First(&target).Error; err != nil {
+			return err
+		}
+		if source.Language != target.Language {
+			return ErrLanguageMismatch
+		}
+
+		if err := saveAliasInTx(tx, keywordNormalized(&source), keywordNormalized(&target)); err != nil {
+			return err
+		}
+		return moveKeywordInTx(tx, &source, &target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// RenameKeyword changes the display name of a keyword. The old name becomes an
+// alias so the generator keeps assigning its articles to the renamed keyword.
+func RenameKeyword(db *gorm.DB, id, name string) (*model.KeyWords, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	n := normalizeKeyword(name)
+	if n == "" {
+		return nil, ErrInvalidKeyword
+	}
+
+	var kw model.KeyWords
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&kw).Error; err != nil {
+			return err
+		}
+
+		old := keywordNormalized(&kw)
+		if old != n {
+			var conflicts int64
+			if err := tx.Model(&model.KeyWords{}).
+				Where("normalized = ? AND language = ? AND id <> ?", n, kw.Language, id).
+				Count(&conflicts).Error; err != nil {
+				return err
+			}
+			if conflicts > 0 {
+				return ErrKeywordConflict
+			}
+			if err := saveAliasInTx(tx, old, n); err != nil {
+				return err
+			}
+		}
+
+		kw.Keyword = name
+		kw.Normalized = &n
+		return tx.Model(&kw).Updates(map[string]interface{}{
+			"keyword":    name,
+			"normalized": n,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kw, nil
+}
+
+// SetKeywordBlocked blocks or unblocks a keyword. Blocked keywords are expired
+// immediately and never regenerated.
+func SetKeywordBlocked(db *gorm.DB, id string, blocked bool) (*model.KeyWords, error) {
+	updates := map[string]interface{}{"blocked": blocked}
+	if blocked {
+		updates["expired_at"] = time.Now()
+		updates["pinned"] = false
+	}
+	return updateKeyword(db, id, updates)
+}
+
+// SetKeywordPinned pins or unpins a keyword. Pinned keywords are listed first
+// and are not expired when a run no longer produces them.
+func SetKeywordPinned(db *gorm.DB, id string, pinned bool) (*model.KeyWords, error) {
+	updates := map[string]interface{}{"pinned": pinned}
+	if pinned {
+		updates["expired_at"] = nil
+		updates["blocked"] = false
+	}
+	return updateKeyword(db, id, updates)
+}
+
+func updateKeyword(db *gorm.DB, id string, updates map[string]interface{}) (*model.KeyWords, error) {
+	var kw model.KeyWords
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&kw).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&kw).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).First(&kw).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kw, nil
+}
+
+func keywordNormalized(kw *model.KeyWords) string {
+	if kw.Normalized != nil {
+		return *kw.Normalized
+	}
+	return normalizeKeyword(kw.Keyword)
+}
+
+// saveAliasInTx stores an active alias and repoints aliases of the variant so
+// chains stay one hop long.
+func saveAliasInTx(tx *gorm.DB, alias, canonical string) error {
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "alias"}},
+		DoUpdates: clause.AssignmentColumns([]string{"canonical", "score", "status", "updated_at"}),
+	}).Create(&model.KeywordAlias{
+		Alias:     alias,
+		Canonical: canonical,
+		Score:     1,
+		Status:    model.KeywordAliasStatusActive,
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&model.KeywordAlias{}).
+		Where("canonical = ?", alias).
+		Update("canonical", canonical).Error; err != nil {
+		return err
+	}
+
+	// The canonical keyword can no longer be an alias of anything
+	return tx.Where("alias = ?", canonical).Delete(&model.KeywordAlias{}).Error
+}
+
+// moveKeywordInTx moves the articles of source onto target and expires source
+func moveKeywordInTx(tx *gorm.DB, source, target *model.KeyWords) error {
+	if err := tx.Exec(`
+		INSERT INTO article_keywords (key_words_id, article_id)
+		SELECT ?, article_id FROM article_keywords WHERE key_words_id = ?
+		ON CONFLICT DO NOTHING
+	`, target.ID, source.ID).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec("DELETE FROM article_keywords WHERE key_words_id = ?", source.ID).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Model(source).Updates(map[string]interface{}{
+		"expired_at": now,
+		"pinned":     false,
+	}).Error; err != nil {
+		return err
+	}
+
+	target.LastUpdate = now
+	return tx.Model(target).UpdateColumn("last_update", now).Error
+}
+
+// SuggestKeywordAliases compares active keywords by name and by the articles
+// they share and records likely variants as alias suggestions for review.
+func SuggestKeywordAliases(db *gorm.DB) error {
+	var keywords []model.KeyWords
+	if err := db.Select("id, keyword, normalized, language").
+		Where("expired_at IS NULL AND normalized IS NOT NULL").
+		Find(&keywords).Error; err != nil {
+		return err
+	}
+	if len(keywords) < 2 {
+		return nil
+	}
+
+	ids := make([]string, len(keywords))
+	for i, kw := range keywords {
+		ids[i] = kw.ID
+	}
+
+	var memberships []struct {
+		KeyWordsID string
+		ArticleID  string
+	}
+	if err := db.Table("article_keywords").
+		Select("key_words_id, article_id").
+		Where("key_words_id IN ?", ids).
+		Scan(&memberships).Error; err != nil {
+		return err
+	}
+
+	articleSets := make(map[string]map[string]bool, len(keywords))
+	for _, m := range memberships {
+		if articleSets[m.KeyWordsID] == nil {
+			articleSets[m.KeyWordsID] = make(map[string]bool)
+		}
+		articleSets[m.KeyWordsID][m.ArticleID] = true
+	}
+
+	var known []string
+	if err := db.Model(&model.KeywordAlias{}).Pluck("alias", &known).Error; err != nil {
+		return err
+	}
+	aliased := make(map[string]bool, len(known))
+	for _, a := range known {
+		aliased[a] = true
+	}
+
+	suggestions := make(map[string]*model.KeywordAlias)
+	for i := range keywords {
+		for j := i + 1; j < len(keywords); j++ {
+			a, b := &keywords[i], &keywords[j]
+			if a.Language != b.Language {
+				continue
+			}
+
+			nameScore := keywordNameSimilarity(*a.Normalized, *b.Normalized)
+			if nameScore < 0.5 {
+				continue
+			}
+			score := 0.5*nameScore + 0.5*overlapCoefficient(articleSets[a.ID], articleSets[b.ID])
+			if score < AliasSuggestionThreshold {
+				continue
+			}
+
+			// The keyword with more articles, then the longer name, is canonical
+			alias, canonical := a, b
+			la, lb := len(articleSets[a.ID]), len(articleSets[b.ID])
+			if la > lb || (la == lb && len(*a.Normalized) > len(*b.Normalized)) {
+				alias, canonical = b, a
+			}
+			if aliased[*alias.Normalized] {
+				continue
+			}
+
+			if existing, ok := suggestions[*alias.Normalized]; ok && existing.Score >= score {
+				continue
+			}
+			suggestions[*alias.Normalized] = &model.KeywordAlias{
+				Alias:     *alias.Normalized,
+				Canonical: *canonical.Normalized,
+				Score:     score,
+				Status:    model.KeywordAliasStatusSuggested,
+			}
+		}
+	}
+
+	if len(suggestions) == 0 {
+		return nil
+	}
+
+	list := make([]*model.KeywordAlias, 0, len(suggestions))
+	for _, s := range suggestions {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Alias < list[j].Alias })
+
+	// Never overwrite an alias an editor already accepted
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "alias"}},
+		DoUpdates: clause.AssignmentColumns([]string{"canonical", "score", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: "keyword_aliases", Name: "status"}, Value: model.KeywordAliasStatusSuggested},
+		}},
+	}).CreateInBatches(list, 100).Error; err != nil {
+		return err
+	}
+
+	Log(Database, "Keyword aliases suggested", "count", len(list))
+	return nil
+}
+
+// keywordNameSimilarity scores how likely two normalized keywords name the same
+// thing: token containment for "merz" and "friedrich merz", edit distance for
+// spelling variants.
+func keywordNameSimilarity(a, b string) float64 {
+	ta, tb := strings.Fields(a), strings.Fields(b)
+	set := make(map[string]bool, len(ta))
+	for _, t := range ta {
+		set[t] = true
+	}
+	shared := 0
+	for _, t := range tb {
+		if set[t] {
+			shared++
+		}
+	}
+
+	smaller := len(ta)
+	if len(tb) < smaller {
+		smaller = len(tb)
+	}
+	containment := 0.0
+	if smaller > 0 {
+		containment = float64(shared) / float64(smaller)
+	}
+
+	longer := len(a)
+	if len(b) > longer {
+		longer = len(b)
+	}
+	edit := 0.0
+	if longer > 0 {
+		edit = 1 - float64(levenshteinDistance(a, b))/float64(longer)
+	}
+
+	if containment > edit {
+		return containment
+	}
+	return edit
+}
+
+func overlapCoefficient(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0.0
+	}
+	shared := 0
+	for id := range a {
+		if b[id] {
+			shared++
+		}
+	}
+	smaller := len(a)
+	if len(b) < smaller {
+		smaller = len(b)
+	}
+	return float64(shared) / float64(smaller)
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"math"
+	"sort"
+
+	"news-swipe/backend/graph/model"
+)
+
+type BlindspotConfig struct {
+	// MinSources is the number of outlets a story needs before its coverage
+	// is considered meaningful.
+	MinSources int
+	// MinShare is the fraction of covering outlets, center included, that
+	// must sit on one side.
+	MinShare float64
+}
+
+func DefaultBlindspotConfig() BlindspotConfig {
+	return BlindspotConfig{
+		MinSources: 3,
+		MinShare:   2.0 / 3,
+	}
+}
+
+var leaningScores = map[model.Leaning]float64{
+	model.LeaningLeft:        -1.0,
+	model.LeaningCenterLeft:  -0.5,
+	model.LeaningCenter:      0.0,
+	model.LeaningCenterRight: 0.5,
+	model.LeaningRight:       1.0,
+}
+
+// CoverageSkew returns the mean leaning of the given sources in [-1, 1],
+// where negative values mean left-leaning coverage, along with the number of
+// left- and right-leaning outlets.
+func CoverageSkew(sources []model.Source) (skew float64, left, right int) {
+	if len(sources) == 0 {
+		return 0, 0, 0
+	}
+
+	total := 0.0
+	for _, source := range sources {
+		score := leaningScores[GetSourceMetadata(source).Leaning]
+		total += score
+		switch {
+		case score < 0:
+			left++
+		case score > 0:
+			right++
+		}
+	}
+	return total / float64(len(sources)), left, right
+}
+
+// FindBlindspots returns the stories whose coverage comes predominantly from
+// one side of the spectrum, most skewed first.
+func FindBlindspots(stories []*model.Story, config BlindspotConfig) []*model.Blindspot {
+	blindspots := make([]*model.Blindspot, 0)
+
+	for _, story := range stories {
+		if len(story.Sources) < config.MinSources {
+			continue
+		}
+
+		covered := make([]model.Source, 0, len(story.Sources))
+		coveredSet := make(map[model.Source]bool, len(story.Sources))
+		for _, s := range story.Sources {
+			source := model.Source(s)
+			covered = append(covered, source)
+			coveredSet[source] = true
+		}
+
+		skew, left, right := CoverageSkew(covered)
+		if left+right == 0 {
+			continue
+		}
+
+		leaning := model.LeaningLeft
+		dominant := left
+		if right > left {
+			leaning = model.LeaningRight
+			dominant = right
+		}
+		// Center outlets count towards the total so a single leaning outlet
+		// next to a center one does not make a blindspot
+		if float64(dominant)/float64(len(covered)) < config.MinShare {
+			continue
+		}
+
+		missing := make([]model.Source, 0)
+		for _, source := range model.AllSource {
+			if !coveredSet[source] {
+				missing = append(missing, source)
+			}
+		}
+
+		blindspots = append(blindspots, &model.Blindspot{
+			Story:          story,
+			Leaning:        leaning,
+			Skew:           skew,
+			CoveredBy:      covered,
+			MissingSources: missing,
+		})
+	}
+
+	sort.SliceStable(blindspots, func(i, j int) bool {
+		si, sj := math.Abs(blindspots[i].Skew), math.Abs(blindspots[j].Skew)
+		if si != sj {
+			return si > sj
+		}
+		return blindspots[i].Story.LastUpdated.After(blindspots[j].Story.LastUpdated)
+	})
+
+	return blindspots
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+)
+
+// MinEdgeWeight is the lowest normalized PMI stored in the keyword graph.
+// Pairs at or below zero co-occur no more often than chance.
+const MinEdgeWeight = 0.0
+
+// MinSharedArticles is how many articles two keywords must share to be
+// connected. NPMI is 1 for two keywords that each occur in one and the same
+// article, so single shared articles would outrank real topics.
+const MinSharedArticles = 2
+
+// BuildKeywordGraph connects active keywords that share articles. Edges are
+// weighted by normalized PMI so pairs of large keywords do not dominate just
+// because they have many articles.
+func BuildKeywordGraph(db *gorm.DB) error {
+	var memberships []struct {
+		KeyWordsID string
+		ArticleID  string
+	}
+	if err := db.Table("article_keywords ak").
+		Select("ak.key_words_id, ak.article_id").
+		Joins("JOIN key_words k ON k.id = ak.key_words_id AND k.expired_at IS NULL AND k.deleted_at IS NULL").
+		Scan(&memberships).Error; err != nil {
+		return err
+	}
+
+	keywordsByArticle := make(map[string][]string)
+	articleCount := make(map[string]int)
+	for _, m := range memberships {
+		keywordsByArticle[m.ArticleID] = append(keywordsByArticle[m.ArticleID], m.KeyWordsID)
+		articleCount[m.KeyWordsID]++
+	}
+
+	type pair struct{ a, b string }
+	shared := make(map[pair]int)
+	for _, kws := range keywordsByArticle {
+		sort.Strings(kws)
+		for i := 0; i < len(kws); i++ {
+			for j := i + 1; j < len(kws); j++ {
+				if kws[i] != kws[j] {
+					shared[pair{kws[i], kws[j]}]++
+				}
+			}
+		}
+	}
+
+	total := float64(len(keywordsByArticle))
+	now := time.Now()
+	edges := make([]model.KeywordEdge, 0, len(shared)*2)
+	for p, n := range shared {
+		if n < MinSharedArticles {
+			continue
+		}
+		weight := normalizedPMI(n, articleCount[p.a], articleCount[p.b], total)
+		if weight <= MinEdgeWeight {
+			continue
+		}
+		edges = append(edges,
+			model.KeywordEdge{KeyWordsID: p.a, RelatedID: p.b, SharedArticles: int32(n), Weight: weight, UpdatedAt: now},
+			model.KeywordEdge{KeyWordsID: p.b, RelatedID: p.a, SharedArticles: int32(n), Weight: weight, UpdatedAt: now},
+		)
+	}
+
+	// Edges are derived data, so the graph is replaced as a whole. Readers
+	// keep seeing the previous graph until the transaction commits.
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM keyword_edges").Error; err != nil {
+			return err
+		}
+		if len(edges) > 0 {
+			if err := tx.CreateInBatches(edges, 500).Error; err != nil {
+				return err
+			}
+		}
+		Log(Database, "Keyword graph rebuilt", "edges", len(edges)/2)
+		return nil
+	})
+}
+
+// normalizedPMI maps pointwise mutual information onto [-1, 1], where 1 means
+// the keywords only ever appear together.
+func normalizedPMI(shared, countA, countB int, total float64) float64 {
+	if shared == 0 || countA == 0 || countB == 0 || total == 0 {
+		return -1.0
+	}
+
+	pAB := float64(shared) / total
+	if pAB >= 1 {
+		return 1.0
+	}
+	pA := float64(countA) / total
+	pB := float64(countB) / total
+
+	return math.Log(pAB/(pA*pB)) / -math.Log(pAB)
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"sort"
+
+	"news-swipe/backend/graph/model"
+)
+
+// BuildStoryCoverage summarises how each outlet covered a story: the earliest
+// article per source, how long after the first report it appeared, and which
+// outlets did not cover the story at all. It returns nil when none of the
+// story's articles are left, since there is nothing to compare.
+func BuildStoryCoverage(story *model.Story, articles []*model.Article) *model.StoryCoverage {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	coverage := &model.StoryCoverage{
+		Story:          story,
+		Sources:        []*model.SourceCoverage{},
+		MissingSources: []model.Source{},
+	}
+
+	sorted := make([]*model.Article, len(articles))
+	copy(sorted, articles)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PublishedAt.Before(sorted[j].PublishedAt)
+	})
+
+	first := sorted[0]
+	coverage.BrokenBy = first.Source
+	coverage.BrokenAt = first.PublishedAt
+
+	bySource := make(map[model.Source]*model.SourceCoverage)
+	for _, a := range sorted {
+		if existing, ok := bySource[a.Source]; ok {
+			existing.ArticleCount++
+			continue
+		}
+
+		entry := &model.SourceCoverage{
+			Source:       a.Source,
+			Article:      a,
+			Headline:     a.Title,
+			Description:  a.Description,
+			PublishedAt:  a.PublishedAt,
+			DelayMinutes: int32(a.PublishedAt.Sub(first.PublishedAt).Minutes()),
+			ArticleCount: 1,
+		}
+		bySource[a.Source] = entry
+		coverage.Sources = append(coverage.Sources, entry)
+	}
+
+	for _, source := range model.AllSource {
+		if _, ok := bySource[source]; !ok {
+			coverage.MissingSources = append(coverage.MissingSources, source)
+		}
+	}
+
+	return coverage
+}
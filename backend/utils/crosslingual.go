@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/pemistahl/lingua-go"
+)
+
+// bilingualLexicon maps German and English news vocabulary onto a shared
+// canonical form. Proper names that are spelled the same in both languages do
+// not need an entry; only translations and transliteration variants do.
+var bilingualLexicon = map[string]string{
+	// Countries, regions and organisations
+	"deutschland": "germany", "germany": "germany",
+	"frankreich": "france", "france": "france",
+	"russland": "russia", "russia": "russia",
+	"ukraine": "ukraine",
+	"polen":   "poland", "poland": "poland",
+	"türkei": "turkey", "turkey": "turkey", "türkiye": "turkey",
+	"italien": "italy", "italy": "italy",
+	"spanien": "spain", "spain": "spain",
+	"griechenland": "greece", "greece": "greece",
+	"österreich": "austria", "austria": "austria",
+	"schweiz": "switzerland", "switzerland": "switzerland",
+	"niederlande": "netherlands", "netherlands": "netherlands",
+	"großbritannien": "britain", "britain": "britain",
+	"vereinigten": "united", "united": "united",
+	"usa": "usa", "amerika": "usa", "america": "usa",
+	"china":  "china",
+	"japan":  "japan",
+	"indien": "india", "india": "india",
+	"iran": "iran",
+	"irak": "iraq", "iraq": "iraq",
+	"israel": "israel",
+	"syrien": "syria", "syria": "syria",
+	"libanon": "lebanon", "lebanon": "lebanon",
+	"ägypten": "egypt", "egypt": "egypt",
+	"gaza": "gaza", "gazastreifen": "gaza",
+	"europa": "europe", "europe": "europe",
+	"eu": "eu", "nato": "nato", "uno": "un",
+	"bundestag": "bundestag", "bundesrat": "bundesrat",
+	"bundesregierung": "government", "regierung": "government", "government": "government",
+	"bundeswehr": "bundeswehr",
+	// Cities
+	"berlin":  "berlin",
+	"münchen": "munich", "munich": "munich",
+	"köln": "cologne", "cologne": "cologne",
+	"wien": "vienna", "vienna": "vienna",
+	"moskau": "moscow", "moscow": "moscow",
+	"kiew": "kyiv", "kyjiw": "kyiv", "kyiv": "kyiv", "kiev": "kyiv",
+	"peking": "beijing", "beijing": "beijing",
+	"warschau": "warsaw", "warsaw": "warsaw",
+	"rom": "rome", "rome": "rome",
+	"brüssel": "brussels", "brussels": "brussels",
+	"washington": "washington",
+	"jerusalem":  "jerusalem",
+	// Names with differing spellings
+	"selenskyj": "zelensky", "zelensky": "zelensky", "zelenskyy": "zelensky",
+	"netanjahu": "netanyahu", "netanyahu": "netanyahu",
+	"chamenei": "khamenei", "khamenei": "khamenei",
+	"erdoğan": "erdogan", "erdogan": "erdogan",
+	// Common news vocabulary
+	"kanzler": "chancellor", "bundeskanzler": "chancellor", "kanzlerin": "chancellor", "chancellor": "chancellor",
+	"präsident": "president", "präsidentin": "president", "president": "president",
+	"minister": "minister", "ministerin": "minister",
+	"wahl": "election", "wahlen": "election", "election": "election", "elections": "election",
+	"krieg": "war", "war": "war",
+	"waffenruhe": "ceasefire", "waffenstillstand": "ceasefire", "ceasefire": "ceasefire",
+	"angriff": "attack", "angriffe": "attack", "attack": "attack", "attacks": "attack",
+	"anschlag": "attack",
+	"gipfel":   "summit", "summit": "summit",
+	"zölle": "tariffs", "zoll": "tariffs", "tariffs": "tariffs", "tariff": "tariffs",
+	"inflation": "inflation",
+	"streik":    "strike", "strike": "strike",
+	"erdbeben": "earthquake", "earthquake": "earthquake",
+	"hochwasser": "flood", "überschwemmung": "flood", "flood": "flood", "floods": "flood",
+	"tote": "dead", "toten": "dead", "dead": "dead", "killed": "dead",
+	"verletzte": "injured", "injured": "injured",
+	"polizei": "police", "police": "police",
+	"gericht": "court", "court": "court",
+	"klima": "climate", "climate": "climate",
+	"migration": "migration", "migranten": "migrants", "migrants": "migrants",
+	"flüchtlinge": "refugees", "refugees": "refugees",
+	"rakete": "missile", "raketen": "missile", "missile": "missile", "missiles": "missile",
+	"drohne": "drone", "drohnen": "drone", "drone": "drone", "drones": "drone",
+}
+
+// acronyms are matched case-sensitively because their lowercase forms are
+// ordinary words ("us", "un").
+var acronyms = map[string]string{
+	"US": "usa",
+	"UN": "un",
+	"UK": "britain",
+}
+
+// German capitalises every noun, so capitalisation alone would turn most of a
+// German text into anchors while only names count on the English side. These
+// common nouns and noun suffixes are skipped for German text; names rarely
+// end in them, and translations that matter are in the lexicon already.
+var germanCommonNouns = map[string]bool{
+	"menschen": true, "mensch": true, "leute": true, "kinder": true, "kind": true,
+	"frau": true, "frauen": true, "mann": true, "männer": true, "familie": true,
+	"jahr": true, "jahre": true, "jahren": true, "monat": true, "monate": true,
+	"monaten": true, "woche": true, "wochen": true, "tag": true, "tage": true,
+	"tagen": true, "stunden": true, "minuten": true, "zeit": true, "ende": true,
+	"anfang": true, "prozent": true, "millionen": true, "milliarden": true,
+	"zahl": true, "zahlen": true, "daten": true, "kosten": true, "preis": true,
+	"preise": true, "geld": true, "steuer": true, "steuern": true, "land": true,
+	"länder": true, "stadt": true, "städte": true, "ort": true, "haus": true,
+	"straße": true, "schule": true, "arbeit": true, "partei": true, "parteien": true,
+	"minister": true, "ministerin": true, "chef": true, "chefin": true,
+	"kanzler": true, "bürger": true, "kunden": true, "experten": true,
+	"politik": true, "kritik": true, "streit": true, "plan": true, "pläne": true,
+	"fall": true, "fälle": true, "frage": true, "fragen": true, "grund": true,
+	"teil": true, "seite": true, "ziel": true, "weg": true, "bericht": true,
+	"berichte": true, "studie": true, "wahl": true, "wahlen": true, "krieg": true,
+	"gesetz": true, "unternehmen": true, "firma": true, "angst": true, "hilfe": true,
+	"video": true, "foto": true, "bild": true, "bilder": true, "interview": true,
+	"kommentar": true, "analyse": true, "nachrichten": true, "überblick": true,
+	"liveticker": true, "ticker": true, "gespräch": true, "gespräche": true,
+	"treffen": true,
+}
+
+var germanNounSuffixes = []string{
+	"ung", "ungen", "heit", "heiten", "keit", "keiten", "schaft", "schaften",
+	"tion", "tionen", "tät", "täten", "ismus", "nis", "nisse",
+}
+
+func isGermanCommonNoun(lower string) bool {
+	if germanCommonNouns[lower] {
+		return true
+	}
+	for _, suffix := range germanNounSuffixes {
+		if strings.HasSuffix(lower, suffix) && len(lower) > len(suffix)+2 {
+			return true
+		}
+	}
+	return false
+}
+
+// isCrossLingual reports whether two articles are written in different known
+// languages and must be compared through language-independent anchors.
+func isCrossLingual(a1, a2 model.Article) bool {
+	l1, l2 := a1.Language.ToLingua(), a2.Language.ToLingua()
+	return l1 != l2 && l1 != lingua.Unknown && l2 != lingua.Unknown
+}
+
+// extractAnchors returns the tokens of a text that survive translation:
+// numbers, capitalised words (names, places, organisations) and words found in
+// the bilingual lexicon, mapped to their canonical form. Capitalised common
+// nouns of German text are not anchors.
+func extractAnchors(text string, lang lingua.Language) map[string]bool {
+	stopwords := stopwordsForLanguage(lang)
+	anchors := make(map[string]bool)
+
+	for _, raw := range strings.Fields(text) {
+		word := cleanWord(raw)
+		if word == "" {
+			continue
+		}
+		if canonical, ok := acronyms[word]; ok {
+			anchors[canonical] = true
+			continue
+		}
+
+		lower := strings.ToLower(word)
+		if stopwords[lower] {
+			continue
+		}
+
+		if canonical, ok := bilingualLexicon[lower]; ok {
+			anchors[canonical] = true
+			continue
+		}
+
+		if isNumber(word) {
+			anchors[strings.ReplaceAll(word, ",", ".")] = true
+			continue
+		}
+
+		first := []rune(word)[0]
+		if unicode.IsUpper(first) && len(word) > 2 {
+			if lang == lingua.German && isGermanCommonNoun(lower) {
+				continue
+			}
+			anchors[lower] = true
+		}
+	}
+
+	return anchors
+}
+
+func isNumber(word string) bool {
+	hasDigit := false
+	for _, r := range word {
+		switch {
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case r == '.' || r == ',':
+		default:
+			return false
+		}
+	}
+	return hasDigit
+}
+
+// anchorSimilarity scores two texts in different languages by the overlap
+// coefficient of their anchors. At least two shared anchors are required so a
+// single common name does not link unrelated stories.
+func anchorSimilarity(s1 string, lang1 lingua.Language, s2 string, lang2 lingua.Language) float64 {
+	anchors1 := extractAnchors(s1, lang1)
+	anchors2 := extractAnchors(s2, lang2)
+
+	if len(anchors1) == 0 || len(anchors2) == 0 {
+		return 0.0
+	}
+
+	shared := 0
+	for a := range anchors1 {
+		if anchors2[a] {
+			shared++
+		}
+	}
+	if shared < 2 {
+		return 0.0
+	}
+
+	smaller := len(anchors1)
+	if len(anchors2) < smaller {
+		smaller = len(anchors2)
+	}
+	return float64(shared) / float64(smaller)
+}
+
+func sharedAnchors(a1, a2 model.Article) []string {
+	anchors1 := extractAnchors(a1.Title+" "+a1.Description, a1.Language.ToLingua())
+	anchors2 := extractAnchors(a2.Title+" "+a2.Description, a2.Language.ToLingua())
+
+	shared := make([]string, 0)
+	for a := range anchors1 {
+		if anchors2[a] {
+			shared = append(shared, a)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
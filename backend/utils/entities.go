@@ -0,0 +1,426 @@
+package utils
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/pemistahl/lingua-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExtractedEntity is a named entity found in an article.
+type ExtractedEntity struct {
+	Name string
+	Type model.EntityType
+}
+
+type gazetteerEntry struct {
+	name       string
+	entityType model.EntityType
+}
+
+// gazetteer maps lowercase surface forms, including short forms such as a
+// surname, to the canonical entity name.
+var gazetteer = buildGazetteer(map[model.EntityType]map[string][]string{
+	model.EntityTypePerson: {
+		"Friedrich Merz":          {"merz"},
+		"Olaf Scholz":             {"scholz"},
+		"Lars Klingbeil":          {"klingbeil"},
+		"Boris Pistorius":         {"pistorius"},
+		"Markus Söder":            {"söder"},
+		"Alice Weidel":            {"weidel"},
+		"Robert Habeck":           {"habeck"},
+		"Christian Lindner":       {"lindner"},
+		"Annalena Baerbock":       {"baerbock"},
+		"Johann Wadephul":         {"wadephul"},
+		"Alexander Dobrindt":      {"dobrindt"},
+		"Sahra Wagenknecht":       {"wagenknecht"},
+		"Frank-Walter Steinmeier": {"steinmeier"},
+		"Ursula von der Leyen":    {"von der leyen"},
+		"Donald Trump":            {"trump"},
+		"Joe Biden":               {"biden"},
+		"Wladimir Putin":          {"putin", "vladimir putin"},
+		"Wolodymyr Selenskyj":     {"selenskyj", "zelensky", "volodymyr zelensky"},
+		"Emmanuel Macron":         {"macron"},
+		"Keir Starmer":            {"starmer"},
+		"Giorgia Meloni":          {"meloni"},
+		"Benjamin Netanjahu":      {"netanjahu", "netanyahu", "benjamin netanyahu"},
+		"Recep Tayyip Erdoğan":    {"erdoğan", "erdogan"},
+		"Xi Jinping":              {"xi"},
+		"Papst Leo XIV.":          {"leo xiv", "papst leo"},
+		"Elon Musk":               {"musk"},
+	},
+	model.EntityTypeOrganization: {
+		"CDU":                      {},
+		"CSU":                      {},
+		"SPD":                      {},
+		"AfD":                      {},
+		"FDP":                      {},
+		"BSW":                      {},
+		"Grüne":                    {"grünen", "die grünen", "greens"},
+		"Die Linke":                {"linke", "linken", "linkspartei"},
+		"Bundestag":                {},
+		"Bundesrat":                {},
+		"Bundesregierung":          {},
+		"Bundeswehr":               {},
+		"Bundesverfassungsgericht": {"verfassungsgericht"},
+		"Europäische Union":        {"eu", "european union"},
+		"Europäische Zentralbank":  {"ezb", "ecb", "european central bank"},
+		"Nato":                     {},
+		"Vereinte Nationen":        {"uno", "un", "united nations"},
+		"Bundesbank":               {},
+		"Deutsche Bahn":            {"bahn"},
+		"Volkswagen":               {"vw"},
+		"Siemens":                  {},
+		"BMW":                      {},
+		"Mercedes-Benz":            {"mercedes"},
+		"Lufthansa":                {},
+		"Hamas":                    {},
+		"Hisbollah":                {"hezbollah"},
+		"Google":                   {},
+		"Apple":                    {},
+		"Meta":                     {},
+		"Tesla":                    {},
+		"OpenAI":                   {},
+		"FC Bayern München":        {"fc bayern", "bayern münchen"},
+	},
+	model.EntityTypeLocation: {
+		"Deutschland":         {"germany"},
+		"Berlin":              {},
+		"Hamburg":             {},
+		"München":             {"munich"},
+		"Köln":                {"cologne"},
+		"Frankfurt":           {},
+		"Stuttgart":           {},
+		"Düsseldorf":          {},
+		"Leipzig":             {},
+		"Dresden":             {},
+		"Bayern":              {"bavaria"},
+		"Sachsen":             {"saxony"},
+		"Thüringen":           {"thuringia"},
+		"Brandenburg":         {},
+		"Nordrhein-Westfalen": {"nrw"},
+		"Baden-Württemberg":   {},
+		"Niedersachsen":       {},
+		"Hessen":              {"hesse"},
+		"Europa":              {"europe"},
+		"Brüssel":             {"brussels"},
+		"Frankreich":          {"france"},
+		"Paris":               {},
+		"Großbritannien":      {"britain", "uk"},
+		"London":              {},
+		"USA":                 {"us", "vereinigte staaten", "united states"},
+		"Washington":          {},
+		"Russland":            {"russia"},
+		"Moskau":              {"moscow"},
+		"Ukraine":             {},
+		"Kiew":                {"kyjiw", "kyiv", "kiev"},
+		"Polen":               {"poland"},
+		"Österreich":          {"austria"},
+		"Wien":                {"vienna"},
+		"Schweiz":             {"switzerland"},
+		"Italien":             {"italy"},
+		"Rom":                 {"rome"},
+		"Spanien":             {"spain"},
+		"Türkei":              {"turkey", "türkiye"},
+		"Israel":              {},
+		"Gaza":                {"gazastreifen", "gaza strip"},
+		"Iran":                {},
+		"Syrien":              {"syria"},
+		"China":               {},
+		"Peking":              {"beijing"},
+		"Japan":               {},
+		"Indien":              {"india"},
+	},
+})
+
+func buildGazetteer(entries map[model.EntityType]map[string][]string) map[string]gazetteerEntry {
+	result := make(map[string]gazetteerEntry)
+	for entityType, names := range entries {
+		for name, aliases := range names {
+			entry := gazetteerEntry{name: name, entityType: entityType}
+			result[strings.ToLower(name)] = entry
+			for _, alias := range aliases {
+				result[alias] = entry
+			}
+		}
+	}
+	return result
+}
+
+// personCues precede a person's name, e.g. "Kanzler Merz" or "President Macron".
+var personCues = map[string]bool{
+	"kanzler": true, "kanzlerin": true, "bundeskanzler": true, "präsident": true,
+	"präsidentin": true, "minister": true, "ministerin": true, "chef": true,
+	"chefin": true, "vorsitzende": true, "vorsitzender": true, "herr": true,
+	"frau": true, "bürgermeister": true, "bürgermeisterin": true, "papst": true,
+	"president": true, "chancellor": true, "prime": true, "senator": true,
+	"mr": true, "mrs": true, "ms": true, "dr": true, "ceo": true, "pope": true,
+}
+
+// organizationSuffixes mark a capitalised sequence as an organisation.
+var organizationSuffixes = map[string]bool{
+	"ag": true, "gmbh": true, "se": true, "kg": true, "inc": true, "ltd": true,
+	"corp": true, "group": true, "gruppe": true, "partei": true, "party": true,
+	"ministerium": true, "ministry": true, "verband": true, "bank": true,
+	"universität": true, "university": true, "institut": true, "institute": true,
+	"gericht": true, "court": true, "stiftung": true, "foundation": true,
+	"gewerkschaft": true, "union": true, "rat": true, "council": true,
+}
+
+// locationCues precede a place name, e.g. "in Leipzig" or "aus Kiew".
+var locationCues = map[string]bool{
+	"in": true, "nach": true, "aus": true, "bei": true, "from": true,
+	"near": true, "to": true,
+}
+
+// locationSuffixes are common endings of German place names.
+var locationSuffixes = []string{"stadt", "burg", "dorf", "hausen", "heim", "berg", "furt", "ingen", "bach"}
+
+type entityToken struct {
+	text          string
+	lower         string
+	capitalized   bool
+	sentenceStart bool
+	// boundary is set when punctuation follows the token
+	boundary bool
+}
+
+func entityTokens(text string) []entityToken {
+	fields := strings.Fields(text)
+	tokens := make([]entityToken, 0, len(fields))
+	sentenceStart := true
+
+	for _, field := range fields {
+		word := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+		})
+		if word != "" {
+			first := []rune(word)[0]
+			tokens = append(tokens, entityToken{
+				text:          word,
+				lower:         strings.ToLower(word),
+				capitalized:   unicode.IsUpper(first),
+				sentenceStart: sentenceStart,
+				boundary:      strings.ContainsAny(field[len(field)-1:], ".,;:!?)"),
+			})
+		}
+		sentenceStart = strings.ContainsAny(field[len(field)-1:], ".!?:")
+	}
+	return tokens
+}
+
+// ExtractEntities tags persons, organisations and locations in a text using the
+// gazetteer first and capitalisation cues for names it does not know.
+func ExtractEntities(text string, lang lingua.Language) []ExtractedEntity {
+	stopwords := AllStopwords()
+	tokens := entityTokens(text)
+	found := make(map[ExtractedEntity]bool)
+	result := make([]ExtractedEntity, 0)
+
+	add := func(name string, entityType model.EntityType) {
+		e := ExtractedEntity{Name: name, Type: entityType}
+		if !found[e] {
+			found[e] = true
+			result = append(result, e)
+		}
+	}
+
+	for i := 0; i < len(tokens); {
+		// Longest gazetteer match of up to four tokens
+		matched := 0
+		longest := 4
+		if len(tokens)-i < longest {
+			longest = len(tokens) - i
+		}
+		for n := longest; n > 0; n-- {
+			parts := make([]string, n)
+			for k := 0; k < n; k++ {
+				parts[k] = tokens[i+k].lower
+			}
+			if entry, ok := gazetteer[strings.Join(parts, " ")]; ok {
+				if n == 1 && !tokens[i].capitalized {
+					// Single lowercase words such as "us" or "bahn" are too ambiguous
+					break
+				}
+				add(entry.name, entry.entityType)
+				matched = n
+				break
+			}
+		}
+		if matched > 0 {
+			i += matched
+			continue
+		}
+
+		if !tokens[i].capitalized || stopwords[tokens[i].lower] || personCues[tokens[i].lower] {
+			i++
+			continue
+		}
+
+		// Collect a run of capitalised tokens within the same clause
+		end := i + 1
+		for end < len(tokens) && !tokens[end-1].boundary && isNameToken(tokens[end], stopwords) {
+			end++
+		}
+		run := tokens[i:end]
+
+		if entityType, ok := classifyRun(tokens, i, run, lang); ok {
+			names := make([]string, len(run))
+			for k, t := range run {
+				names[k] = t.text
+			}
+			add(strings.Join(names, " "), entityType)
+		}
+		i = end
+	}
+
+	return result
+}
+
+func isNameToken(t entityToken, stopwords map[string]bool) bool {
+	if !t.capitalized || stopwords[t.lower] || personCues[t.lower] {
+		return false
+	}
+	_, known := gazetteer[t.lower]
+	return !known
+}
+
+func classifyRun(tokens []entityToken, start int, run []entityToken, lang lingua.Language) (model.EntityType, bool) {
+	last := run[len(run)-1].lower
+	if organizationSuffixes[last] && len(run) > 1 {
+		return model.EntityTypeOrganization, true
+	}
+
+	var prev string
+	if start > 0 {
+		prev = tokens[start-1].lower
+	}
+
+	// Titles and roles introduce people, e.g. "Ministerin Katherina Reiche"
+	if personCues[prev] {
+		if len(run) <= 3 {
+			return model.EntityTypePerson, true
+		}
+		return "", false
+	}
+
+	if locationCues[prev] && len(run) == 1 {
+		for _, suffix := range locationSuffixes {
+			if strings.HasSuffix(last, suffix) && len(last) > len(suffix)+2 {
+				return model.EntityTypeLocation, true
+			}
+		}
+	}
+
+	// Two or three capitalised words that do not start a sentence are most
+	// likely a person's full name in English text. German capitalises nouns,
+	// so runs such as "Deutsche Bahn" are only names there after a cue.
+	if lang == lingua.English && len(run) >= 2 && len(run) <= 3 && !run[0].sentenceStart {
+		for _, t := range run {
+			if len([]rune(t.text)) < 2 || strings.ToUpper(t.text) == t.text {
+				return "", false
+			}
+		}
+		return model.EntityTypePerson, true
+	}
+
+	return "", false
+}
+
+// ExtractEntitiesFromArticles tags every article that has not been processed
+// yet and links it to its entities.
+func ExtractEntitiesFromArticles(db *gorm.DB) error {
+	var articles []model.Article
+	if err := db.Select("id, title, description, language").
+		Where("entities_extracted_at IS NULL").
+		Find(&articles).Error; err != nil {
+		return err
+	}
+	if len(articles) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		return persistEntitiesInTx(tx, articles)
+	})
+}
+
+func persistEntitiesInTx(tx *gorm.DB, articles []model.Article) error {
+	articleEntities := make(map[string][]ExtractedEntity, len(articles))
+	unique := make(map[ExtractedEntity]bool)
+	articleIDs := make([]string, 0, len(articles))
+
+	for _, a := range articles {
+		entities := ExtractEntities(a.Title+". "+a.Description, a.Language.ToLingua())
+		articleEntities[a.ID] = entities
+		articleIDs = append(articleIDs, a.ID)
+		for _, e := range entities {
+			unique[e] = true
+		}
+	}
+
+	if len(unique) > 0 {
+		entityList := make([]*model.Entity, 0, len(unique))
+		names := make([]string, 0, len(unique))
+		for e := range unique {
+			entityList = append(entityList, &model.Entity{Name: e.Name, Type: e.Type})
+			names = append(names, e.Name)
+		}
+
+		if err := tx.Omit("Articles").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}, {Name: "type"}},
+			DoNothing: true,
+		}).CreateInBatches(entityList, 100).Error; err != nil {
+			return err
+		}
+
+		var dbEntities []model.Entity
+		if err := tx.Select("id, name, type").Where("name IN ?", names).Find(&dbEntities).Error; err != nil {
+			return err
+		}
+
+		idMap := make(map[ExtractedEntity]string, len(dbEntities))
+		for _, de := range dbEntities {
+			idMap[ExtractedEntity{Name: de.Name, Type: de.Type}] = de.ID
+		}
+
+		joinRows := make([]map[string]interface{}, 0, len(articles)*3)
+		for artID, entities := range articleEntities {
+			for _, e := range entities {
+				if entityID, ok := idMap[e]; ok {
+					joinRows = append(joinRows, map[string]interface{}{
+						"entity_id":  entityID,
+						"article_id": artID,
+					})
+				}
+			}
+		}
+
+		const batchSize = 500
+		for i := 0; i < len(joinRows); i += batchSize {
+			end := i + batchSize
+			if end > len(joinRows) {
+				end = len(joinRows)
+			}
+			if err := tx.Table("article_entities").
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(joinRows[i:end]).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Model(&model.Article{}).
+		Where("id IN ?", articleIDs).
+		UpdateColumn("entities_extracted_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	Log(Database, "Entities extracted", "articles", len(articles), "entities", len(unique))
+	return nil
+}
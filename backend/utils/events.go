@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TopicArticleAdded carries the articles stored by a scrape run
+	TopicArticleAdded = "article_added"
+	// TopicStoryUpdated carries the IDs of stories that were created or changed
+	TopicStoryUpdated = "story_updated"
+
+	eventsChannelPrefix = "veritas:events:"
+	// subscriberBuffer is how many events a slow subscriber may lag behind
+	// before further events are dropped for it
+	subscriberBuffer = 64
+)
+
+// event is the envelope sent through Redis. Origin lets a replica skip its
+// own events, which were already delivered locally.
+type event struct {
+	Origin  string          `json:"origin"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Broker fans events out to the subscribers of this process and, through
+// Redis pub/sub, to the subscribers of every other replica.
+type Broker struct {
+	id   string
+	mu   sync.RWMutex
+	subs map[string]map[chan json.RawMessage]struct{}
+}
+
+// Events is the broker shared by the cron jobs and the subscription resolvers
+var Events = NewBroker()
+
+func NewBroker() *Broker {
+	return &Broker{
+		id:   uuid.NewString(),
+		subs: make(map[string]map[chan json.RawMessage]struct{}),
+	}
+}
+
+// Publish delivers payload to the subscribers of topic on all replicas
+func (b *Broker) Publish(ctx context.Context, topic string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	b.deliver(topic, data)
+
+	if RedisClient == nil {
+		return nil
+	}
+	msg, err := json.Marshal(event{Origin: b.id, Payload: data})
+	if err != nil {
+		return err
+	}
+	return RedisClient.Publish(ctx, eventsChannelPrefix+topic, msg).Err()
+}
+
+// Subscribe returns the payloads published to topic until ctx is done, when
+// the channel is closed.
+func (b *Broker) Subscribe(ctx context.Context, topic string) <-chan json.RawMessage {
+	ch := make(chan json.RawMessage, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[chan json.RawMessage]struct{})
+	}
+	b.subs[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs[topic], ch)
+		b.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+// deliver never blocks the publisher; events for subscribers whose buffer is
+// full are dropped.
+func (b *Broker) deliver(topic string, data json.RawMessage) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subs[topic] {
+		select {
+		case ch <- data:
+		default:
+			Log(GraphQL, "Dropping event for slow subscriber", "topic", topic)
+		}
+	}
+}
+
+// Listen relays events published by other replicas until ctx is done. It
+// returns immediately when Redis is not configured.
+func (b *Broker) Listen(ctx context.Context) {
+	if RedisClient == nil {
+		return
+	}
+
+	sub := RedisClient.PSubscribe(ctx, eventsChannelPrefix+"*")
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var e event
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				Log(GraphQL, "Ignoring malformed event", "channel", msg.Channel, "error", err)
+				continue
+			}
+			if e.Origin == b.id {
+				continue
+			}
+			b.deliver(strings.TrimPrefix(msg.Channel, eventsChannelPrefix), e.Payload)
+		}
+	}
+}
+
+// PublishArticlesAdded announces newly stored articles. Relations are left
+// out to keep messages small.
+func PublishArticlesAdded(ctx context.Context, articles []model.Article) {
+	if len(articles) == 0 {
+		return
+	}
+
+	stripped := make([]model.Article, len(articles))
+	for i, a := range articles {
+		a.LinkedTo = nil
+		a.LinkedFrom = nil
+		a.Keywords = nil
+		stripped[i] = a
+	}
+	if err := Events.Publish(ctx, TopicArticleAdded, stripped); err != nil {
+		Log(GraphQL, "Failed to publish new articles", "error", err)
+	}
+}
+
+// PublishStoriesUpdated announces stories that were created or changed
+func PublishStoriesUpdated(ctx context.Context, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	if err := Events.Publish(ctx, TopicStoryUpdated, ids); err != nil {
+		Log(GraphQL, "Failed to publish updated stories", "error", err)
+	}
+}
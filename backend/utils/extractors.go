@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/pemistahl/lingua-go"
+)
+
+// KeywordExtractor ranks the keyphrases of a cluster of related articles
+type KeywordExtractor interface {
+	Name() string
+	// Extract returns at most max keyphrases, best first
+	Extract(cluster []model.Article, max int) []ScoredKeyword
+}
+
+// ScoredKeyword is a lowercase keyphrase with its extractor-specific score
+type ScoredKeyword struct {
+	Keyword string
+	Score   float64
+}
+
+// KeywordExtractorNames lists the extractors selectable via KEYWORD_EXTRACTOR
+var KeywordExtractorNames = []string{"frequency", "textrank", "rake"}
+
+var (
+	keywordExtractor     KeywordExtractor
+	keywordExtractorOnce sync.Once
+)
+
+// NewKeywordExtractor returns the extractor registered under name
+func NewKeywordExtractor(name string) (KeywordExtractor, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "frequency":
+		return FrequencyExtractor{}, nil
+	case "textrank":
+		return DefaultTextRankExtractor(), nil
+	case "rake":
+		return DefaultRakeExtractor(), nil
+	default:
+		return nil, fmt.Errorf("unknown keyword extractor %q", name)
+	}
+}
+
+// getKeywordExtractor returns the extractor chosen by KEYWORD_EXTRACTOR,
+// falling back to the frequency scorer.
+func getKeywordExtractor() KeywordExtractor {
+	keywordExtractorOnce.Do(func() {
+		extractor, err := NewKeywordExtractor(os.Getenv("KEYWORD_EXTRACTOR"))
+		if err != nil {
+			Log(System, "Invalid KEYWORD_EXTRACTOR, using frequency", "error", err)
+			extractor = FrequencyExtractor{}
+		}
+		keywordExtractor = extractor
+		Log(System, "Keyword extractor selected", "extractor", extractor.Name())
+	})
+	return keywordExtractor
+}
+
+// FrequencyExtractor scores unigrams and bigrams by weighted raw counts
+type FrequencyExtractor struct{}
+
+func (FrequencyExtractor) Name() string { return "frequency" }
+
+func (FrequencyExtractor) Extract(cluster []model.Article, max int) []ScoredKeyword {
+	lang := lingua.Unknown
+	if len(cluster) > 0 {
+		lang = cluster[0].Language.ToLingua()
+	}
+	candidates := extractCandidates(cluster, newTokenFilter(lang))
+	top := selectTopKeywords(candidates, max)
+
+	scored := make([]ScoredKeyword, len(top))
+	for i, kw := range top {
+		scored[i] = ScoredKeyword{Keyword: kw, Score: float64(candidates[kw])}
+	}
+	return scored
+}
+
+// streamToken is one word of an article in the token stream shared by the
+// graph and phrase based extractors.
+type streamToken struct {
+	surface string
+	stem    string
+	// candidate is false for stopwords, short words and blocked terms
+	candidate bool
+	// boundary marks punctuation after the token, which ends a phrase
+	boundary bool
+}
+
+// tokenStreams splits every title and description of a cluster into a
+// separate stream so phrases never span two texts.
+func tokenStreams(cluster []model.Article) [][]streamToken {
+	streams := make([][]streamToken, 0, len(cluster)*2)
+
+	for _, a := range cluster {
+		lang := a.Language.ToLingua()
+		filter := newTokenFilter(lang)
+		for _, text := range []string{a.Title, a.Description} {
+			fields := strings.Fields(strings.ToLower(text))
+			stream := make([]streamToken, 0, len(fields))
+			for _, field := range fields {
+				word := cleanWord(field)
+				if word == "" {
+					if len(stream) > 0 {
+						stream[len(stream)-1].boundary = true
+					}
+					continue
+				}
+				stream = append(stream, streamToken{
+					surface:   word,
+					stem:      stem(word, lang),
+					candidate: len(word) > 3 && filter.keep(word),
+					boundary:  strings.ContainsAny(field[len(field)-1:], ".,;:!?\"'»«“”)"),
+				})
+			}
+			if len(stream) > 0 {
+				streams = append(streams, stream)
+			}
+		}
+		filter.flush()
+	}
+	return streams
+}
+
+// phraseForms tracks the surface spellings of stemmed phrases so results can
+// be shown in their most common form.
+type phraseForms map[string]map[string]int
+
+func (p phraseForms) add(tokens []streamToken) string {
+	stems := make([]string, len(tokens))
+	surfaces := make([]string, len(tokens))
+	for i, t := range tokens {
+		stems[i] = t.stem
+		surfaces[i] = t.surface
+	}
+	key := strings.Join(stems, " ")
+	if p[key] == nil {
+		p[key] = make(map[string]int)
+	}
+	p[key][strings.Join(surfaces, " ")]++
+	return key
+}
+
+func (p phraseForms) surface(key string) string {
+	best, bestCount := "", 0
+	for form, count := range p[key] {
+		if count > bestCount || (count == bestCount && form < best) {
+			best, bestCount = form, count
+		}
+	}
+	return best
+}
+
+// topPhrases orders stemmed phrase scores and maps them back to surface forms
+func topPhrases(scores map[string]float64, forms phraseForms, max int) []ScoredKeyword {
+	keys := make([]string, 0, len(scores))
+	for k := range scores {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if scores[keys[i]] != scores[keys[j]] {
+			return scores[keys[i]] > scores[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > max {
+		keys = keys[:max]
+	}
+
+	result := make([]ScoredKeyword, len(keys))
+	for i, k := range keys {
+		result[i] = ScoredKeyword{Keyword: forms.surface(k), Score: scores[k]}
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"slices"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// ApplyArticleFilter restricts an articles query to the filter. Unset fields
+// do not restrict; callers fill in the language before applying it.
+func ApplyArticleFilter(query *gorm.DB, filter *model.ArticleFilter) *gorm.DB {
+	if filter == nil {
+		return query
+	}
+	if filter.Language != nil {
+		query = query.Where("articles.language = ?", *filter.Language)
+	}
+	if len(filter.Sources) > 0 {
+		query = query.Where("articles.source IN ?", filter.Sources)
+	}
+	if len(filter.Categories) > 0 {
+		query = query.Where("articles.category && ?", pq.StringArray(filter.Categories))
+	}
+	if filter.From != nil {
+		query = query.Where("articles.published_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("articles.published_at < ?", *filter.To)
+	}
+	if filter.ExcludePaywalled != nil && *filter.ExcludePaywalled {
+		query = query.Where("articles.paywalled = ?", false)
+	}
+	return query
+}
+
+// MatchesArticleFilter reports whether an article passes the filter, mirroring
+// ApplyArticleFilter for articles that are already loaded.
+func MatchesArticleFilter(a *model.Article, filter *model.ArticleFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.Language != nil && a.Language != *filter.Language {
+		return false
+	}
+	if len(filter.Sources) > 0 && !slices.Contains(filter.Sources, a.Source) {
+		return false
+	}
+	if len(filter.Categories) > 0 && !slices.ContainsFunc(filter.Categories, func(c string) bool {
+		return slices.Contains(a.Category, c)
+	}) {
+		return false
+	}
+	if filter.From != nil && a.PublishedAt.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && !a.PublishedAt.Before(*filter.To) {
+		return false
+	}
+	if filter.ExcludePaywalled != nil && *filter.ExcludePaywalled && a.Paywalled {
+		return false
+	}
+	return true
+}
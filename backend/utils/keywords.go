@@ -3,7 +3,6 @@ package utils
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"maps"
 	"sort"
 	"strings"
 	"sync"
@@ -13,73 +12,74 @@ import (
 	"news-swipe/backend/graph/model"
 
 	"github.com/google/uuid"
+	"github.com/pemistahl/lingua-go"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-var (
-	stopwordsCache      map[string]bool
-	stopwordsCacheOnce  sync.Once
-	titleFormatterCache cases.Caser
-	formatterOnce       sync.Once
-	stringBuilderPool   = sync.Pool{
-		New: func() interface{} {
-			return &strings.Builder{}
-		},
-	}
-)
-
-func getStopwords() map[string]bool {
-	stopwordsCacheOnce.Do(func() {
-		stopwordsCache = make(map[string]bool)
-		maps.Copy(stopwordsCache, StopwordsEn)
-		maps.Copy(stopwordsCache, StopwordsDe)
-	})
-	return stopwordsCache
+var stringBuilderPool = sync.Pool{
+	New: func() interface{} {
+		return &strings.Builder{}
+	},
 }
 
-func getTitleFormatter() cases.Caser {
-	formatterOnce.Do(func() {
-		titleFormatterCache = cases.Title(language.German)
-	})
-	return titleFormatterCache
+// titleCaser returns a caser for keyword display names. Casers are stateful,
+// so each run gets its own.
+func titleCaser(lang lingua.Language) cases.Caser {
+	tag := language.Und
+	if lang != lingua.Unknown {
+		tag = language.Make(strings.ToLower(lang.IsoCode639_1().String()))
+	}
+	return cases.Title(tag)
 }
 
+// GenerateKeywordsFromArticles derives keywords from the last two weeks of
+// articles and reconciles them with the stored ones. Each language is
+// clustered and stored separately. Keywords keep their ID across runs; ones
+// that no longer apply are expired instead of deleted.
 func GenerateKeywordsFromArticles(db *gorm.DB) error {
 	cutoff := time.Now().AddDate(0, 0, -14)
 
 	var articles []model.Article
-	if err := db.Select("id, title, description, published_at").
+	if err := db.Select("id, title, description, published_at, language").
 		Where("published_at >= ?", cutoff).
 		Find(&articles).Error; err != nil {
 		return err
 	}
-	if len(articles) == 0 {
-		return nil
+
+	byLanguage := make(map[model.Language][]model.Article)
+	for _, a := range deduplicateByTitle(articles) {
+		byLanguage[a.Language] = append(byLanguage[a.Language], a)
 	}
 
-	articles = deduplicateByTitle(articles)
 	config := DefaultSimilarityConfig()
-	clusters := clusterArticles(articles, 0.36, config)
-	keywords := extractAndMergeKeywords(clusters)
+	extractor := getKeywordExtractor()
+	keywordsByLanguage := make(map[model.Language]map[string]*keywordData, len(byLanguage))
+	for lang, group := range byLanguage {
+		clusters := clusterArticles(group, KeywordClusterThreshold, config)
+		keywordsByLanguage[lang] = extractAndMergeKeywords(clusters, extractor, lang.ToLingua())
+	}
 
 	return db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Exec("DELETE FROM article_keywords").Error; err != nil {
-			return err
-		}
-
-		if err := tx.Exec("DELETE FROM key_words").Error; err != nil {
-			return err
+		languages := make([]model.Language, 0, len(keywordsByLanguage))
+		for lang, keywords := range keywordsByLanguage {
+			if err := persistKeywordsInTx(tx, lang, keywords); err != nil {
+				return err
+			}
+			languages = append(languages, lang)
 		}
 
-		if len(keywords) == 0 {
-			return nil
+		// Languages without recent articles no longer have active keywords
+		expire := tx.Model(&model.KeyWords{}).Where("expired_at IS NULL AND pinned = ?", false)
+		if len(languages) > 0 {
+			expire = expire.Where("language NOT IN ?", languages)
 		}
-
-		return persistKeywordsInTx(tx, keywords)
+		return expire.UpdateColumn("expired_at", time.Now()).Error
 	})
 }
+
 func deduplicateByTitle(articles []model.Article) []model.Article {
 	seen := make(map[string]bool, len(articles))
 	result := make([]model.Article, 0, len(articles))
@@ -124,14 +124,13 @@ func clusterArticles(articles []model.Article, threshold float64, config Similar
 
 type keywordData struct {
 	keyword        string
-	totalFrequency int
+	totalFrequency float64
 	articleSet     map[string]bool
 	articles       []string
 }
 
-func extractAndMergeKeywords(clusters [][]model.Article) map[string]*keywordData {
-	stopwords := getStopwords()
-	titleFormatter := getTitleFormatter()
+func extractAndMergeKeywords(clusters [][]model.Article, extractor KeywordExtractor, lang lingua.Language) map[string]*keywordData {
+	titleFormatter := titleCaser(lang)
 
 	keywordToData := make(map[string]*keywordData)
 
@@ -148,12 +147,9 @@ func extractAndMergeKeywords(clusters [][]model.Article) map[string]*keywordData
 		}
 		sort.Strings(articleIDs)
 
-		candidates := extractCandidates(cluster, stopwords)
-		topKeywords := selectTopKeywords(candidates, 8)
-
-		for _, kw := range topKeywords {
-			cleaned := cleanKeyword(kw)
-			if len(cleaned) < 4 || isCommonEntity(strings.ToLower(cleaned)) {
+		for _, scored := range extractor.Extract(cluster, 8) {
+			cleaned := cleanKeyword(scored.Keyword)
+			if len(cleaned) < 4 || isBlockedPhrase(cleaned, lang) {
 				continue
 			}
 
@@ -166,7 +162,7 @@ func extractAndMergeKeywords(clusters [][]model.Article) map[string]*keywordData
 						existing.articles = append(existing.articles, artID)
 					}
 				}
-				existing.totalFrequency += candidates[kw]
+				existing.totalFrequency += scored.Score
 			} else {
 				articleSetCopy := make(map[string]bool, len(articleSet))
 				for k, v := range articleSet {
@@ -177,7 +173,7 @@ func extractAndMergeKeywords(clusters [][]model.Article) map[string]*keywordData
 
 				keywordToData[formatted] = &keywordData{
 					keyword:        formatted,
-					totalFrequency: candidates[kw],
+					totalFrequency: scored.Score,
 					articleSet:     articleSetCopy,
 					articles:       articlesCopy,
 				}
@@ -299,8 +295,9 @@ func hashArticleSet(articleIDs []string) string {
 	return hex.EncodeToString(hash[:16])
 }
 
-func extractCandidates(cluster []model.Article, stopwords map[string]bool) map[string]int {
+func extractCandidates(cluster []model.Article, filter *tokenFilter) map[string]int {
 	candidates := make(map[string]int, 50)
+	defer filter.flush()
 
 	for _, article := range cluster {
 		sb := stringBuilderPool.Get().(*strings.Builder)
@@ -312,16 +309,20 @@ func extractCandidates(cluster []model.Article, stopwords map[string]bool) map[s
 		stringBuilderPool.Put(sb)
 
 		words := tokenize(text)
+		keep := make([]bool, len(words))
+		for i, w := range words {
+			keep[i] = len(w) > 3 && filter.keep(w)
+		}
 
 		for i := 0; i < len(words); i++ {
 			w := words[i]
-			if len(w) > 3 && !stopwords[w] && !isCommonEntity(w) {
+			if keep[i] {
 				candidates[w] += 3
 			}
 
 			if i < len(words)-1 {
 				w2 := words[i+1]
-				if len(w2) > 3 && !stopwords[w2] {
+				if keep[i+1] {
 					sb := stringBuilderPool.Get().(*strings.Builder)
 					sb.Reset()
 					sb.WriteString(w)
@@ -371,68 +372,179 @@ func selectTopKeywords(candidates map[string]int, max int) []string {
 	return selected
 }
 
-func persistKeywordsInTx(tx *gorm.DB, keywords map[string]*keywordData) error {
-	if len(keywords) == 0 {
-		return nil
+// normalizeKeyword returns the form keywords are matched on between runs
+func normalizeKeyword(keyword string) string {
+	return strings.Join(strings.Fields(strings.ToLower(keyword)), " ")
+}
+
+func persistKeywordsInTx(tx *gorm.DB, lang model.Language, keywords map[string]*keywordData) error {
+	now := time.Now()
+
+	curation, err := loadKeywordCuration(tx, lang)
+	if err != nil {
+		return err
+	}
+
+	// Apply editorial decisions: drop blocked keywords and fold aliases into
+	// their canonical keyword
+	byNormalized := make(map[string]*keywordData, len(keywords))
+	for _, data := range keywords {
+		n := curation.canonical(normalizeKeyword(data.keyword))
+		if curation.blocked[n] {
+			continue
+		}
+
+		existing, ok := byNormalized[n]
+		if !ok {
+			byNormalized[n] = data
+			continue
+		}
+		for _, artID := range data.articles {
+			if !existing.articleSet[artID] {
+				existing.articleSet[artID] = true
+				existing.articles = append(existing.articles, artID)
+			}
+		}
+		existing.totalFrequency += data.totalFrequency
+	}
+	normalized := make([]string, 0, len(byNormalized))
+	for n := range byNormalized {
+		normalized = append(normalized, n)
 	}
 
-	kwList := make([]*model.KeyWords, 0, len(keywords))
-	kwNames := make([]string, 0, len(keywords))
+	// Expire keywords that were not produced by this run unless pinned
+	expire := tx.Model(&model.KeyWords{}).Where("expired_at IS NULL AND pinned = ? AND language = ?", false, lang)
+	if len(normalized) > 0 {
+		expire = expire.Where("normalized IS NULL OR normalized NOT IN ?", normalized)
+	}
+	if err := expire.UpdateColumn("expired_at", now).Error; err != nil {
+		return err
+	}
 
-	for name := range keywords {
+	if len(normalized) == 0 {
+		return nil
+	}
+
+	kwList := make([]*model.KeyWords, 0, len(normalized))
+	for _, n := range normalized {
 		kwList = append(kwList, &model.KeyWords{
 			GormModel:  model.GormModel{ID: uuid.NewString()},
-			Keyword:    name,
-			LastUpdate: time.Now(),
+			Keyword:    byNormalized[n].keyword,
+			LastUpdate: now,
+			Normalized: &n,
+			Language:   lang,
 		})
-		kwNames = append(kwNames, name)
 	}
 
-	if err := tx.Omit("Articles").CreateInBatches(kwList, 100).Error; err != nil {
+	// Existing keywords keep their ID and display name, which may have been
+	// set by an editor, and are revived if they had expired
+	if err := tx.Omit("Articles").Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "normalized"}, {Name: "language"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"expired_at": nil,
+			"deleted_at": nil,
+		}),
+	}).CreateInBatches(kwList, 100).Error; err != nil {
 		return err
 	}
 
 	var dbKws []model.KeyWords
-	if err := tx.Select("id, keyword").Where("keyword IN ?", kwNames).Find(&dbKws).Error; err != nil {
+	if err := tx.Select("id, normalized").Where("normalized IN ? AND language = ?", normalized, lang).Find(&dbKws).Error; err != nil {
 		return err
 	}
 
 	idMap := make(map[string]string, len(dbKws))
+	kwIDs := make([]string, 0, len(dbKws))
 	for _, dk := range dbKws {
-		idMap[dk.Keyword] = dk.ID
+		if dk.Normalized != nil {
+			idMap[*dk.Normalized] = dk.ID
+			kwIDs = append(kwIDs, dk.ID)
+		}
+	}
+
+	var existing []struct {
+		KeyWordsID string
+		ArticleID  string
+	}
+	if err := tx.Table("article_keywords").
+		Select("key_words_id, article_id").
+		Where("key_words_id IN ?", kwIDs).
+		Scan(&existing).Error; err != nil {
+		return err
+	}
+
+	current := make(map[string]map[string]bool, len(kwIDs))
+	for _, row := range existing {
+		if current[row.KeyWordsID] == nil {
+			current[row.KeyWordsID] = make(map[string]bool)
+		}
+		current[row.KeyWordsID][row.ArticleID] = true
 	}
 
-	joinRows := make([]map[string]interface{}, 0, len(keywords)*10)
-	for name, data := range keywords {
-		kwID, ok := idMap[name]
+	var joinRows []map[string]interface{}
+	var changed []string
+	for n, data := range byNormalized {
+		kwID, ok := idMap[n]
 		if !ok {
 			continue
 		}
 
+		added := false
 		for _, artID := range data.articles {
+			if current[kwID][artID] {
+				continue
+			}
 			joinRows = append(joinRows, map[string]interface{}{
 				"key_words_id": kwID,
 				"article_id":   artID,
 			})
+			added = true
 		}
-	}
 
-	if len(joinRows) > 0 {
-		const batchSize = 500
-		for i := 0; i < len(joinRows); i += batchSize {
-			end := i + batchSize
-			if end > len(joinRows) {
-				end = len(joinRows)
+		var removed []string
+		for artID := range current[kwID] {
+			if !data.articleSet[artID] {
+				removed = append(removed, artID)
 			}
-
-			if err := tx.Table("article_keywords").Create(joinRows[i:end]).Error; err != nil {
+		}
+		if len(removed) > 0 {
+			if err := tx.Exec("DELETE FROM article_keywords WHERE key_words_id = ? AND article_id IN ?", kwID, removed).Error; err != nil {
 				return err
 			}
 		}
+
+		if added {
+			changed = append(changed, kwID)
+		}
+	}
+
+	const batchSize = 500
+	for i := 0; i < len(joinRows); i += batchSize {
+		end := i + batchSize
+		if end > len(joinRows) {
+			end = len(joinRows)
+		}
+
+		if err := tx.Table("article_keywords").
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(joinRows[i:end]).Error; err != nil {
+			return err
+		}
 	}
 
+	// Only keywords that gained articles move up in the listing
+	if len(changed) > 0 {
+		if err := tx.Model(&model.KeyWords{}).
+			Where("id IN ?", changed).
+			UpdateColumn("last_update", now).Error; err != nil {
+			return err
+		}
+	}
+
+	Log(Database, "Keywords updated", "active", len(normalized), "changed", len(changed), "links", len(joinRows))
 	return nil
 }
+
 func tokenize(text string) []string {
 	words := strings.Fields(strings.ToLower(text))
 	result := make([]string, len(words))
@@ -453,15 +565,3 @@ func cleanKeyword(kw string) string {
 		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
 	})
 }
-
-var commonEntities = map[string]bool{
-	"news":    true,
-	"article": true,
-	"report":  true,
-	"says":    true,
-	"heute":   true,
-}
-
-func isCommonEntity(word string) bool {
-	return commonEntities[word]
-}
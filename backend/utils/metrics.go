@@ -98,6 +98,23 @@ var (
 		[]string{"source"},
 	)
 
+	ArticleViewsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "veritas_article_views_total",
+			Help: "Total number of article views by kind (impression, open, duplicate_open)",
+		},
+		[]string{"kind"},
+	)
+
+	// Keyword metrics
+	KeywordTokensFilteredTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "veritas_keyword_tokens_filtered_total",
+			Help: "Total number of tokens dropped from keyword extraction by reason and language",
+		},
+		[]string{"reason", "language"},
+	)
+
 	// Cron job metrics
 	CronJobRunsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
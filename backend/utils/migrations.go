@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+)
+
+// preMigrations run before AutoMigrate, for columns AutoMigrate could not add
+// to tables that already hold rows.
+var preMigrations = []string{
+	// key_words.language is NOT NULL, which only holds once existing keywords
+	// are backfilled below
+	`ALTER TABLE IF EXISTS key_words ADD COLUMN IF NOT EXISTS language text`,
+}
+
+// migrations are raw statements GORM's AutoMigrate cannot express. Each must
+// be idempotent since they run on every start.
+var migrations = []string{
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	`CREATE INDEX IF NOT EXISTS idx_articles_title_trgm ON articles USING GIN (title gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_articles_description_trgm ON articles USING GIN (description gin_trgm_ops)`,
+	// Keywords stored before they were matched by their normalized form get
+	// it, so the next run updates them instead of replacing them. Of legacy
+	// keywords sharing a form, the active, pinned or most recent one is kept;
+	// the others stay unmatched and are expired by the next run.
+	`WITH legacy AS (
+		SELECT id, n, row_number() OVER (
+			PARTITION BY n ORDER BY expired_at IS NULL DESC, pinned DESC, last_update DESC, id
+		) AS rank
+		FROM (
+			SELECT id, expired_at, pinned, last_update,
+				btrim(regexp_replace(lower(keyword), '\s+', ' ', 'g')) AS n
+			FROM key_words
+			WHERE normalized IS NULL
+		) candidates
+	)
+	UPDATE key_words
+	SET normalized = legacy.n
+	FROM legacy
+	WHERE key_words.id = legacy.id
+		AND legacy.rank = 1
+		AND legacy.n <> ''
+		AND NOT EXISTS (SELECT 1 FROM key_words other WHERE other.normalized = legacy.n)`,
+	// Keywords stored before they were generated per language take the
+	// language most of their articles are written in, so they keep their ID,
+	// curation, trend points and edges. A legacy keyword whose form already
+	// exists in that language gives the form up and is expired by the next
+	// run.
+	`WITH majority AS (
+		SELECT DISTINCT ON (ak.key_words_id) ak.key_words_id AS id, a.language
+		FROM article_keywords ak
+		JOIN key_words k ON k.id = ak.key_words_id AND k.language IS NULL
+		JOIN articles a ON a.id = ak.article_id AND a.language IS NOT NULL
+		GROUP BY ak.key_words_id, a.language
+		ORDER BY ak.key_words_id, COUNT(*) DESC, a.language
+	), assigned AS (
+		SELECT k.id, k.normalized, COALESCE(majority.language, 'UNKNOWN') AS language
+		FROM key_words k
+		LEFT JOIN majority ON majority.id = k.id
+		WHERE k.language IS NULL
+	)
+	UPDATE key_words
+	SET language = assigned.language,
+		normalized = CASE WHEN EXISTS (
+			SELECT 1 FROM key_words other
+			WHERE other.normalized = assigned.normalized AND other.language = assigned.language
+		) THEN NULL ELSE assigned.normalized END
+	FROM assigned
+	WHERE key_words.id = assigned.id`,
+	`ALTER TABLE key_words ALTER COLUMN language SET NOT NULL`,
+	// Keywords are unique per language since they are generated per language
+	`DROP INDEX IF EXISTS idx_key_words_normalized`,
+	// Stories used to be soft deleted on every rebuild
+	`DELETE FROM stories WHERE deleted_at IS NOT NULL`,
+	// Full-text search; titles weigh more than descriptions
+	`ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		CASE language
+			WHEN 'DE' THEN setweight(to_tsvector('german'::regconfig, coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('german'::regconfig, coalesce(description, '')), 'B')
+			WHEN 'EN' THEN setweight(to_tsvector('english'::regconfig, coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('english'::regconfig, coalesce(description, '')), 'B')
+			ELSE setweight(to_tsvector('simple'::regconfig, coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('simple'::regconfig, coalesce(description, '')), 'B')
+		END
+	) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_articles_search_vector ON articles USING GIN (search_vector)`,
+}
+
+// Migrate brings the database schema up to date
+func Migrate(db *gorm.DB) error {
+	for _, stmt := range preMigrations {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.AutoMigrate(&model.Article{}, &model.KeyWords{}, &model.Story{}, &model.Entity{}, &model.KeywordTrendPoint{}, &model.KeywordAlias{}, &model.KeywordEdge{}, &model.WordListEntry{}, &model.ArticleViewBucket{}, &model.ScrapeRun{}); err != nil {
+		return err
+	}
+
+	for _, stmt := range migrations {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	Log(Database, "Migrations applied", "statements", len(preMigrations)+len(migrations))
+	return nil
+}
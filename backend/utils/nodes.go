@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+)
+
+// Global IDs identify every object implementing Node across all types. They
+// are the base64 of "<Type>:<id>", so the type and database key can be read
+// back. Arguments also accept the raw database IDs used before.
+const (
+	NodeArticle  = "Article"
+	NodeKeyWords = "KeyWords"
+	NodeStory    = "Story"
+	NodeEntity   = "Entity"
+)
+
+var nodeTypes = []string{NodeArticle, NodeKeyWords, NodeStory, NodeEntity}
+
+// GlobalID returns the global ID of an object
+func GlobalID(typ, id string) string {
+	return base64.StdEncoding.EncodeToString([]byte(typ + ":" + id))
+}
+
+// DecodeGlobalID returns the type and database ID of a global ID. ok is
+// false for raw IDs.
+func DecodeGlobalID(id string) (typ, raw string, ok bool) {
+	decoded, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return "", "", false
+	}
+	typ, raw, found := strings.Cut(string(decoded), ":")
+	if !found || raw == "" {
+		return "", "", false
+	}
+	for _, t := range nodeTypes {
+		if t == typ {
+			return typ, raw, true
+		}
+	}
+	return "", "", false
+}
+
+// LocalID returns the database ID of an argument that is either a global ID
+// of the given type or a raw ID. Global IDs of other types are returned
+// unchanged and so match nothing.
+func LocalID(typ, id string) string {
+	if t, raw, ok := DecodeGlobalID(id); ok && t == typ {
+		return raw
+	}
+	return id
+}
+
+// LoadNodes returns the objects of global IDs in the given order, with nil
+// for IDs that match nothing. Raw IDs are looked up in every type, since
+// article IDs and the UUIDs of the other types do not collide. Articles
+// outside filter match nothing.
+func LoadNodes(db *gorm.DB, ids []string, filter *model.ArticleFilter) ([]model.Node, error) {
+	byType := make(map[string][]string)
+	var rawIDs []string
+	for _, id := range ids {
+		if typ, raw, ok := DecodeGlobalID(id); ok {
+			byType[typ] = append(byType[typ], raw)
+		} else {
+			rawIDs = append(rawIDs, id)
+		}
+	}
+	if len(rawIDs) > 0 {
+		for _, typ := range nodeTypes {
+			byType[typ] = append(byType[typ], rawIDs...)
+		}
+	}
+
+	// found is keyed by type and database ID
+	found := make(map[string]model.Node)
+	for _, typ := range nodeTypes {
+		if len(byType[typ]) == 0 {
+			continue
+		}
+		nodes, err := loadNodesOfType(db, typ, byType[typ], filter)
+		if err != nil {
+			return nil, err
+		}
+		for id, node := range nodes {
+			found[typ+":"+id] = node
+		}
+	}
+
+	result := make([]model.Node, len(ids))
+	for i, id := range ids {
+		if typ, raw, ok := DecodeGlobalID(id); ok {
+			result[i] = found[typ+":"+raw]
+			continue
+		}
+		for _, typ := range nodeTypes {
+			if node, ok := found[typ+":"+id]; ok {
+				result[i] = node
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func loadNodesOfType(db *gorm.DB, typ string, ids []string, filter *model.ArticleFilter) (map[string]model.Node, error) {
+	nodes := make(map[string]model.Node, len(ids))
+
+	switch typ {
+	case NodeArticle:
+		var articles []*model.Article
+		if err := ApplyArticleFilter(db, filter).Where("articles.id IN ?", ids).Find(&articles).Error; err != nil {
+			return nil, err
+		}
+		for _, a := range articles {
+			nodes[a.ID] = a
+		}
+	case NodeKeyWords:
+		var keywords []*model.KeyWords
+		if err := db.Where("id IN ? AND expired_at IS NULL", ids).Find(&keywords).Error; err != nil {
+			return nil, err
+		}
+		for _, k := range keywords {
+			nodes[k.ID] = k
+		}
+	case NodeStory:
+		var stories []*model.Story
+		if err := db.Where("id IN ?", ids).Find(&stories).Error; err != nil {
+			return nil, err
+		}
+		for _, s := range stories {
+			nodes[s.ID] = s
+		}
+	case NodeEntity:
+		var entities []*model.Entity
+		if err := db.Where("id IN ?", ids).Find(&entities).Error; err != nil {
+			return nil, err
+		}
+		for _, e := range entities {
+			nodes[e.ID] = e
+		}
+	}
+	return nodes, nil
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultPageSize is used when a connection is requested without first
+	DefaultPageSize = 20
+	// MaxPageSize caps first so a single page stays cheap
+	MaxPageSize = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ArticleCursor is the keyset position of an article in a listing. Cursors
+// are opaque to clients and only valid for the listing that issued them.
+type ArticleCursor struct {
+	// Rank is the sort value of ranked listings, such as views
+	Rank        float64
+	PublishedAt time.Time
+	ID          string
+}
+
+const cursorPrefix = "a1"
+
+// Encode returns the opaque form of the cursor
+func (c ArticleCursor) Encode() string {
+	raw := fmt.Sprintf("%s:%s:%d:%s", cursorPrefix,
+		strconv.FormatFloat(c.Rank, 'g', -1, 64), c.PublishedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeArticleCursor parses a cursor produced by Encode
+func DecodeArticleCursor(cursor string) (ArticleCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ArticleCursor{}, ErrInvalidCursor
+	}
+	// Article IDs may contain colons, so the ID is everything after the third
+	parts := strings.SplitN(string(raw), ":", 4)
+	if len(parts) != 4 || parts[0] != cursorPrefix || parts[3] == "" {
+		return ArticleCursor{}, ErrInvalidCursor
+	}
+	rank, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return ArticleCursor{}, ErrInvalidCursor
+	}
+	micros, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return ArticleCursor{}, ErrInvalidCursor
+	}
+	return ArticleCursor{Rank: rank, PublishedAt: time.UnixMicro(micros).UTC(), ID: parts[3]}, nil
+}
+
+// ArticleOrder is a keyset ordering of articles. Articles are ordered by the
+// optional rank column, then published_at and id, all descending, so the
+// order is total and pages never overlap.
+type ArticleOrder struct {
+	// RankColumn is a numeric column sorted first; empty for chronological
+	// listings
+	RankColumn string
+	Rank       func(a *model.Article) float64
+}
+
+var (
+	// OrderRecent lists the newest articles first
+	OrderRecent = ArticleOrder{}
+	// OrderViews lists the most viewed articles first. Views change while a
+	// client scrolls, so an article may move across a page boundary, but
+	// pages never shift as a whole like with offsets.
+	OrderViews = ArticleOrder{
+		RankColumn: "articles.views",
+		Rank:       func(a *model.Article) float64 { return float64(a.Views) },
+	}
+	// OrderTrending lists articles by their materialized trending score. A
+	// refresh between two pages can move articles across the boundary like
+	// with views.
+	OrderTrending = ArticleOrder{
+		RankColumn: "articles.trending_score",
+		Rank:       func(a *model.Article) float64 { return a.TrendingScore },
+	}
+)
+
+// ArticleSortOrder returns the ordering of a sort argument
+func ArticleSortOrder(sort model.ArticleSort) ArticleOrder {
+	switch sort {
+	case model.ArticleSortTrending:
+		return OrderTrending
+	case model.ArticleSortViews:
+		return OrderViews
+	default:
+		return OrderRecent
+	}
+}
+
+func (o ArticleOrder) cursor(a *model.Article) ArticleCursor {
+	c := ArticleCursor{PublishedAt: a.PublishedAt, ID: a.ID}
+	if o.Rank != nil {
+		c.Rank = o.Rank(a)
+	}
+	return c
+}
+
+func (o ArticleOrder) apply(query *gorm.DB, after *ArticleCursor) *gorm.DB {
+	if after != nil {
+		if o.RankColumn != "" {
+			query = query.Where(
+				fmt.Sprintf("(%s, articles.published_at, articles.id) < (?, ?, ?)", o.RankColumn),
+				after.Rank, after.PublishedAt, after.ID)
+		} else {
+			query = query.Where("(articles.published_at, articles.id) < (?, ?)", after.PublishedAt, after.ID)
+		}
+	}
+	if o.RankColumn != "" {
+		query = query.Order(o.RankColumn + " DESC")
+	}
+	return query.Order("articles.published_at DESC").Order("articles.id DESC")
+}
+
+// Order sorts a query without paginating it
+func (o ArticleOrder) Order(query *gorm.DB) *gorm.DB {
+	return o.apply(query, nil)
+}
+
+// PageSize clamps the requested page size to (0, MaxPageSize]
+func PageSize(first *int32) int {
+	if first == nil || *first <= 0 {
+		return DefaultPageSize
+	}
+	if *first > MaxPageSize {
+		return MaxPageSize
+	}
+	return int(*first)
+}
+
+// ListSize clamps the amount of a plain list to (0, MaxPageSize]. Amounts
+// outside it get the largest list, since a negative limit would otherwise
+// leave the query without one.
+func ListSize(amount int32) int {
+	if amount < 1 || amount > MaxPageSize {
+		return MaxPageSize
+	}
+	return int(amount)
+}
+
+// PaginateArticles loads the page of articles after the given cursor. The
+// query selects the candidate articles; ordering and limits are added here.
+func PaginateArticles(query *gorm.DB, order ArticleOrder, first *int32, after *string) (*model.ArticleConnection, error) {
+	var cursor *ArticleCursor
+	if after != nil && *after != "" {
+		c, err := DecodeArticleCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &c
+	}
+
+	size := PageSize(first)
+
+	// One extra row tells whether another page follows
+	var articles []*model.Article
+	if err := order.apply(query, cursor).Limit(size + 1).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	hasNext := len(articles) > size
+	if hasNext {
+		articles = articles[:size]
+	}
+
+	return NewArticleConnection(articles, order, hasNext, cursor != nil), nil
+}
+
+// NewArticleConnection wraps an ordered page of articles in a connection
+func NewArticleConnection(articles []*model.Article, order ArticleOrder, hasNext, hasPrevious bool) *model.ArticleConnection {
+	conn := &model.ArticleConnection{
+		Edges: make([]*model.ArticleEdge, len(articles)),
+		PageInfo: &model.PageInfo{
+			HasNextPage:     hasNext,
+			HasPreviousPage: hasPrevious,
+		},
+	}
+	for i, a := range articles {
+		conn.Edges[i] = &model.ArticleEdge{Cursor: order.cursor(a).Encode(), Node: a}
+	}
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+	return conn
+}
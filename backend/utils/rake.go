@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"news-swipe/backend/graph/model"
+)
+
+// RakeExtractor implements Rapid Automatic Keyword Extraction: stopwords and
+// punctuation split the text into candidate phrases, and each word is scored
+// by its degree over its frequency within those phrases.
+type RakeExtractor struct {
+	// MaxWords caps the length of a keyphrase
+	MaxWords int
+}
+
+func DefaultRakeExtractor() RakeExtractor {
+	return RakeExtractor{MaxWords: 3}
+}
+
+func (e RakeExtractor) Name() string { return "rake" }
+
+func (e RakeExtractor) Extract(cluster []model.Article, max int) []ScoredKeyword {
+	streams := tokenStreams(cluster)
+
+	type occurrence struct {
+		key    string
+		stems  []string
+		stream int
+	}
+
+	forms := make(phraseForms)
+	var phrases []occurrence
+	for s, stream := range streams {
+		for i := 0; i < len(stream); {
+			if !stream[i].candidate {
+				i++
+				continue
+			}
+			end := i + 1
+			for end < len(stream) && end-i < e.MaxWords && !stream[end-1].boundary && stream[end].candidate {
+				end++
+			}
+
+			stems := make([]string, 0, end-i)
+			for _, t := range stream[i:end] {
+				stems = append(stems, t.stem)
+			}
+			phrases = append(phrases, occurrence{key: forms.add(stream[i:end]), stems: stems, stream: s})
+			i = end
+		}
+	}
+	if len(phrases) == 0 {
+		return nil
+	}
+
+	freq := make(map[string]float64)
+	degree := make(map[string]float64)
+	for _, p := range phrases {
+		for _, w := range p.stems {
+			freq[w]++
+			degree[w] += float64(len(p.stems))
+		}
+	}
+
+	// Plain RAKE favours long phrases seen once. Within a cluster a phrase
+	// matters when several texts use it, so scores are scaled by that count.
+	texts := make(map[string]map[int]bool)
+	base := make(map[string]float64)
+	for _, p := range phrases {
+		if texts[p.key] == nil {
+			texts[p.key] = make(map[int]bool)
+			for _, w := range p.stems {
+				base[p.key] += degree[w] / freq[w]
+			}
+		}
+		texts[p.key][p.stream] = true
+	}
+
+	scores := make(map[string]float64, len(base))
+	for key, score := range base {
+		scores[key] = score * float64(len(texts[key]))
+	}
+
+	return topPhrases(scores, forms, max)
+}
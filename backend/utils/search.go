@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/pemistahl/lingua-go"
+	"gorm.io/gorm"
+)
+
+// headlineOptions configures the ts_headline snippets shown in results
+const headlineOptions = "StartSel=<b>, StopSel=</b>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+var ErrEmptySearchQuery = errors.New("search query contains no searchable words")
+
+// SearchHit is a matching article with its rank and highlighted snippet
+type SearchHit struct {
+	ID          string
+	PublishedAt time.Time
+	Rank        float64
+	Headline    string
+}
+
+// Cursor returns the keyset position of the hit in the result list
+func (h SearchHit) Cursor() ArticleCursor {
+	return ArticleCursor{Rank: h.Rank, PublishedAt: h.PublishedAt, ID: h.ID}
+}
+
+// searchConfig returns the text search configuration matching the
+// search_vector column of articles in a language.
+func searchConfig(lang lingua.Language) string {
+	switch lang {
+	case lingua.German:
+		return "german"
+	case lingua.English:
+		return "english"
+	default:
+		return "simple"
+	}
+}
+
+// ParseSearchQuery turns user input into to_tsquery syntax. Words are ANDed,
+// "quoted phrases" must match in order, a trailing * matches prefixes, OR
+// between two terms matches either and a leading - excludes a term.
+// Operator characters inside words are dropped, so the result is always
+// valid tsquery syntax.
+func ParseSearchQuery(input string) (string, error) {
+	// groups are ANDed; the terms within a group are alternatives
+	var groups [][]string
+	pendingOr := false
+
+	for _, raw := range splitSearchTerms(input) {
+		if raw == "OR" {
+			pendingOr = len(groups) > 0
+			continue
+		}
+
+		negate := false
+		if strings.HasPrefix(raw, "-") && len(raw) > 1 {
+			negate = true
+			raw = raw[1:]
+		}
+		phrase := strings.HasPrefix(raw, "\"")
+		raw = strings.Trim(raw, "\"")
+		prefix := !phrase && strings.HasSuffix(raw, "*")
+
+		words := strings.FieldsFunc(strings.ToLower(raw), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+
+		term := strings.Join(words, " <-> ")
+		if prefix {
+			term += ":*"
+		}
+		if len(words) > 1 {
+			term = "(" + term + ")"
+		}
+		if negate {
+			term = "!" + term
+		}
+
+		if pendingOr {
+			groups[len(groups)-1] = append(groups[len(groups)-1], term)
+		} else {
+			groups = append(groups, []string{term})
+		}
+		pendingOr = false
+	}
+
+	if len(groups) == 0 {
+		return "", ErrEmptySearchQuery
+	}
+
+	parts := make([]string, len(groups))
+	for i, g := range groups {
+		parts[i] = strings.Join(g, " | ")
+		if len(g) > 1 {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " & "), nil
+}
+
+// splitSearchTerms splits on whitespace while keeping quoted phrases,
+// including a leading -, together.
+func splitSearchTerms(input string) []string {
+	var terms []string
+	var current strings.Builder
+	quoted := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			terms = append(terms, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			current.WriteRune(r)
+			if quoted {
+				flush()
+			}
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return terms
+}
+
+// SearchArticles ranks articles matching the query by ts_rank_cd and returns
+// the page after the given cursor. The filter's language selects the text
+// search configuration. The second result reports whether more hits follow.
+func SearchArticles(db *gorm.DB, q string, filter *model.ArticleFilter, first *int32, after *string) ([]SearchHit, bool, error) {
+	tsquery, err := ParseSearchQuery(q)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var cursor *ArticleCursor
+	if after != nil && *after != "" {
+		c, err := DecodeArticleCursor(*after)
+		if err != nil {
+			return nil, false, err
+		}
+		cursor = &c
+	}
+
+	lang := lingua.Unknown
+	if filter != nil && filter.Language != nil {
+		lang = filter.Language.ToLingua()
+	}
+	config := searchConfig(lang)
+	order := ArticleOrder{RankColumn: "ts_rank_cd(articles.search_vector, q)"}
+
+	query := db.Table("articles").
+		Select("articles.id, articles.published_at, ts_rank_cd(articles.search_vector, q) AS rank, "+
+			"ts_headline(?::regconfig, articles.title || '. ' || articles.description, q, ?) AS headline", config, headlineOptions).
+		Joins("CROSS JOIN to_tsquery(?::regconfig, ?) AS q", config, tsquery).
+		Where("articles.deleted_at IS NULL AND articles.search_vector @@ q")
+	query = ApplyArticleFilter(query, filter)
+
+	size := PageSize(first)
+
+	var hits []SearchHit
+	if err := order.apply(query, cursor).Limit(size + 1).Scan(&hits).Error; err != nil {
+		return nil, false, err
+	}
+
+	hasNext := len(hits) > size
+	if hasNext {
+		hits = hits[:size]
+	}
+	return hits, hasNext, nil
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -10,17 +11,45 @@ import (
 	"github.com/pemistahl/lingua-go"
 )
 
+const (
+	// LinkThreshold is the minimum similarity for two articles to be linked
+	// as covering the same story.
+	LinkThreshold = 0.3
+	// KeywordClusterThreshold is the minimum similarity for two articles to
+	// share a keyword cluster.
+	KeywordClusterThreshold = 0.36
+	// CrossLingualLinkThreshold replaces LinkThreshold for articles in
+	// different languages, whose anchor overlap is noisier than text similarity.
+	CrossLingualLinkThreshold = 0.45
+)
+
 type SimilarityConfig struct {
-	TitleWeight     float64
-	DescWeight      float64
-	TimeWeight      float64
-	SourceWeight    float64
-	SameDayBonus    float64
-	SameWeekBonus   float64
-	SameMonthBonus  float64
-	DifferentSource float64
-	MinTitleLength  int
-	MinDescLength   int
+	TitleWeight     float64 `json:"titleWeight"`
+	DescWeight      float64 `json:"descWeight"`
+	TimeWeight      float64 `json:"timeWeight"`
+	SourceWeight    float64 `json:"sourceWeight"`
+	SameDayBonus    float64 `json:"sameDayBonus"`
+	SameWeekBonus   float64 `json:"sameWeekBonus"`
+	SameMonthBonus  float64 `json:"sameMonthBonus"`
+	DifferentSource float64 `json:"differentSource"`
+	MinTitleLength  int     `json:"minTitleLength"`
+	MinDescLength   int     `json:"minDescLength"`
+}
+
+// SimilarityScores holds the unweighted component scores of a comparison.
+type SimilarityScores struct {
+	Title       float64
+	Description float64
+	Time        float64
+	Source      float64
+}
+
+// Weighted combines the component scores using the weights of config.
+func (s SimilarityScores) Weighted(config SimilarityConfig) float64 {
+	return s.Title*config.TitleWeight +
+		s.Description*config.DescWeight +
+		s.Time*config.TimeWeight +
+		s.Source*config.SourceWeight
 }
 
 func DefaultSimilarityConfig() SimilarityConfig {
@@ -42,7 +71,10 @@ var languageDetector = lingua.NewLanguageDetectorBuilder().
 	FromLanguages(lingua.English, lingua.German).
 	Build()
 
-var StopwordsEn = map[string]bool{
+// linkStopwordsEn and linkStopwordsDe are the stopwords of article similarity.
+// They are kept apart from the editable word lists so that editing those
+// does not shift link scores against LinkThreshold.
+var linkStopwordsEn = map[string]bool{
 	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
 	"be": true, "by": true, "for": true, "from": true, "has": true, "he": true,
 	"in": true, "is": true, "it": true, "its": true, "of": true, "on": true,
@@ -51,7 +83,7 @@ var StopwordsEn = map[string]bool{
 	"were": true, "they": true, "their": true, "can": true, "had": true,
 }
 
-var StopwordsDe = map[string]bool{
+var linkStopwordsDe = map[string]bool{
 	"aber": true, "als": true, "am": true, "an": true, "auch": true, "auf": true,
 	"aus": true, "bei": true, "bin": true, "bis": true, "bist": true, "da": true,
 	"das": true, "dass": true, "dem": true, "den": true, "der": true, "des": true,
@@ -81,15 +113,28 @@ func DetectArticleLanguage(title, description string) lingua.Language {
 }
 
 func ArticleSimilarity(a1, a2 model.Article, config SimilarityConfig) float64 {
-	titleSim := enhancedStringSimilarity(a1.Title, a2.Title, a1.Language.ToLingua(), config.MinTitleLength)
-	descSim := enhancedStringSimilarity(a1.Description, a2.Description, a1.Language.ToLingua(), config.MinDescLength)
-	timeSim := timeSimilarityBucketed(a1.PublishedAt, a2.PublishedAt, config)
-	sourceSim := sourceSimilarity(a1.Source, a2.Source, config)
+	return ArticleSimilarityScores(a1, a2, config).Weighted(config)
+}
 
-	return (titleSim*config.TitleWeight +
-		descSim*config.DescWeight +
-		timeSim*config.TimeWeight +
-		sourceSim*config.SourceWeight)
+// ArticleSimilarityScores computes the component scores that ArticleSimilarity
+// weights, so callers can re-weight them without recomputing.
+func ArticleSimilarityScores(a1, a2 model.Article, config SimilarityConfig) SimilarityScores {
+	if isCrossLingual(a1, a2) {
+		lang1, lang2 := a1.Language.ToLingua(), a2.Language.ToLingua()
+		return SimilarityScores{
+			Title:       anchorSimilarity(a1.Title, lang1, a2.Title, lang2),
+			Description: anchorSimilarity(a1.Title+" "+a1.Description, lang1, a2.Title+" "+a2.Description, lang2),
+			Time:        timeSimilarityBucketed(a1.PublishedAt, a2.PublishedAt, config),
+			Source:      sourceSimilarity(a1.Source, a2.Source, config),
+		}
+	}
+
+	return SimilarityScores{
+		Title:       enhancedStringSimilarity(a1.Title, a2.Title, a1.Language.ToLingua(), config.MinTitleLength),
+		Description: enhancedStringSimilarity(a1.Description, a2.Description, a1.Language.ToLingua(), config.MinDescLength),
+		Time:        timeSimilarityBucketed(a1.PublishedAt, a2.PublishedAt, config),
+		Source:      sourceSimilarity(a1.Source, a2.Source, config),
+	}
 }
 
 func enhancedStringSimilarity(s1, s2 string, lang lingua.Language, minLength int) float64 {
@@ -107,18 +152,8 @@ func enhancedStringSimilarity(s1, s2 string, lang lingua.Language, minLength int
 	maxLen := math.Max(float64(len(s1)), float64(len(s2)))
 	levSim := 1.0 - (float64(levDist) / maxLen)
 
-	var stopwords map[string]bool
-	switch lang {
-	case lingua.German:
-		stopwords = StopwordsDe
-	case lingua.English:
-		stopwords = StopwordsEn
-	default:
-		stopwords = StopwordsEn
-	}
-
-	words1 := filterWithStopwords(strings.Fields(s1), stopwords)
-	words2 := filterWithStopwords(strings.Fields(s2), stopwords)
+	words1 := linkTokens(s1, lang)
+	words2 := linkTokens(s2, lang)
 
 	if len(words1) == 0 || len(words2) == 0 {
 		return levSim * 0.5
@@ -150,6 +185,20 @@ func enhancedStringSimilarity(s1, s2 string, lang lingua.Language, minLength int
 	return (levSim*0.4 + jaccardSim*0.6)
 }
 
+// linkTokens splits text into the words the title and description scores
+// compare
+func linkTokens(text string, lang lingua.Language) []string {
+	fields := strings.Fields(strings.ToLower(strings.TrimSpace(text)))
+	return filterWithStopwords(fields, stopwordsForLanguage(lang))
+}
+
+func stopwordsForLanguage(lang lingua.Language) map[string]bool {
+	if lang == lingua.German {
+		return linkStopwordsDe
+	}
+	return linkStopwordsEn
+}
+
 func filterWithStopwords(words []string, stopwords map[string]bool) []string {
 	filtered := make([]string, 0, len(words))
 	for _, w := range words {
@@ -207,21 +256,37 @@ func min(a, b, c int) int {
 	return c
 }
 
-func timeSimilarityBucketed(t1, t2 time.Time, config SimilarityConfig) float64 {
-	if t1.After(t2) {
-		t1, t2 = t2, t1
+const (
+	TimeBucketSameDay   = "SAME_DAY"
+	TimeBucketSameWeek  = "SAME_WEEK"
+	TimeBucketSameMonth = "SAME_MONTH"
+	TimeBucketOlder     = "OLDER"
+)
+
+func timeBucket(t1, t2 time.Time) (string, float64) {
+	hours := math.Abs(t2.Sub(t1).Hours())
+
+	switch {
+	case hours < 24:
+		return TimeBucketSameDay, hours
+	case hours < 24*7:
+		return TimeBucketSameWeek, hours
+	case hours < 24*30:
+		return TimeBucketSameMonth, hours
+	default:
+		return TimeBucketOlder, hours
 	}
+}
 
-	diff := t2.Sub(t1)
-	hours := diff.Hours()
+func timeSimilarityBucketed(t1, t2 time.Time, config SimilarityConfig) float64 {
+	bucket, hours := timeBucket(t1, t2)
 
-	if hours < 24 {
+	switch bucket {
+	case TimeBucketSameDay:
 		return config.SameDayBonus
-	}
-	if hours < 24*7 {
+	case TimeBucketSameWeek:
 		return config.SameWeekBonus
-	}
-	if hours < 24*30 {
+	case TimeBucketSameMonth:
 		return config.SameMonthBonus
 	}
 
@@ -236,6 +301,65 @@ func sourceSimilarity(s1, s2 model.Source, config SimilarityConfig) float64 {
 	return config.DifferentSource
 }
 
+// SimilarityExplanation breaks a similarity score down into the parts that
+// produced it, for debugging unexpected links.
+type SimilarityExplanation struct {
+	Scores       SimilarityScores
+	Score        float64
+	TimeBucket   string
+	SameSource   bool
+	SharedTokens []string
+}
+
+// ExplainSimilarity computes the same score as ArticleSimilarity together with
+// its component scores, the time bucket and the content words both articles share.
+func ExplainSimilarity(a1, a2 model.Article, config SimilarityConfig) SimilarityExplanation {
+	scores := ArticleSimilarityScores(a1, a2, config)
+	bucket, _ := timeBucket(a1.PublishedAt, a2.PublishedAt)
+
+	return SimilarityExplanation{
+		Scores:       scores,
+		Score:        scores.Weighted(config),
+		TimeBucket:   bucket,
+		SameSource:   a1.Source == a2.Source,
+		SharedTokens: sharedTokens(a1, a2),
+	}
+}
+
+func sharedTokens(a1, a2 model.Article) []string {
+	if isCrossLingual(a1, a2) {
+		return sharedAnchors(a1, a2)
+	}
+
+	// The scores compare titles with titles and descriptions with
+	// descriptions, in the language of the first article
+	lang := a1.Language.ToLingua()
+	seen := make(map[string]bool)
+	shared := make([]string, 0)
+	for _, texts := range [][2]string{{a1.Title, a2.Title}, {a1.Description, a2.Description}} {
+		set1 := make(map[string]bool)
+		for _, w := range linkTokens(texts[0], lang) {
+			set1[w] = true
+		}
+		for _, w := range linkTokens(texts[1], lang) {
+			if set1[w] && !seen[w] {
+				seen[w] = true
+				shared = append(shared, w)
+			}
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// LinkThresholdFor returns the link threshold that applies to a pair of articles.
+func LinkThresholdFor(a1, a2 model.Article) float64 {
+	if isCrossLingual(a1, a2) {
+		return CrossLingualLinkThreshold
+	}
+	return LinkThreshold
+}
+
 func IsSimilar(a1, a2 model.Article, threshold float64, config SimilarityConfig) bool {
 	calc := ArticleSimilarity(a1, a2, config)
 	return calc >= threshold
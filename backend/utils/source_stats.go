@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// scrapeRunRetention is how long scrape runs are kept
+	scrapeRunRetention = 7 * 24 * time.Hour
+	// feedHealthWindow is how far back scrape runs decide a feed's health.
+	// It spans several runs of the 15 minute cron so one hiccup does not mark
+	// a feed as failing.
+	feedHealthWindow = 2 * time.Hour
+	// sourceStatsDays is how many days of article counts are reported;
+	// older articles are removed by the cleanup job anyway
+	sourceStatsDays = 7
+)
+
+// RecordScrapeRuns stores the runs of a scrape and drops runs older than the
+// retention.
+func RecordScrapeRuns(db *gorm.DB, runs []model.ScrapeRun) error {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&runs).Error; err != nil {
+			return err
+		}
+		return tx.Where("started_at < ?", time.Now().Add(-scrapeRunRetention)).
+			Delete(&model.ScrapeRun{}).Error
+	})
+}
+
+type scrapeRunStats struct {
+	Source         model.Source
+	LastSuccess    *time.Time
+	RecentRuns     int
+	RecentFailures int
+}
+
+type dailyArticleCount struct {
+	Source model.Source
+	Day    time.Time
+	Count  int32
+}
+
+type publishLatency struct {
+	Source  model.Source
+	Latency float64
+}
+
+// SourceInfos combines the metadata of sources with statistics from their
+// scrape runs and articles, in the order given.
+func SourceInfos(db *gorm.DB, sources []model.Source) ([]*model.SourceInfo, error) {
+	now := time.Now()
+	healthSince := now.Add(-feedHealthWindow)
+	end := now.UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(0, 0, -(sourceStatsDays - 1))
+
+	var runs []scrapeRunStats
+	if err := db.Model(&model.ScrapeRun{}).
+		Select(`source,
+			MAX(started_at) FILTER (WHERE error = '') AS last_success,
+			COUNT(*) FILTER (WHERE started_at >= ?) AS recent_runs,
+			COUNT(*) FILTER (WHERE started_at >= ? AND error <> '') AS recent_failures`, healthSince, healthSince).
+		Where("source IN ?", sources).
+		Group("source").
+		Scan(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []dailyArticleCount
+	if err := db.Model(&model.Article{}).
+		Select("source, date_trunc('day', published_at AT TIME ZONE 'UTC') AS day, COUNT(*) AS count").
+		Where("source IN ? AND published_at >= ?", sources, start).
+		Group("source, day").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	// Articles whose publication date lies after their scrape have a wrong
+	// timestamp and would skew the average
+	var latencies []publishLatency
+	if err := db.Model(&model.Article{}).
+		Select("source, AVG(EXTRACT(EPOCH FROM created_at - published_at)) AS latency").
+		Where("source IN ? AND created_at >= ? AND created_at >= published_at", sources, start).
+		Group("source").
+		Scan(&latencies).Error; err != nil {
+		return nil, err
+	}
+
+	runsBySource := make(map[model.Source]scrapeRunStats, len(runs))
+	for _, r := range runs {
+		runsBySource[r.Source] = r
+	}
+	countsBySource := make(map[model.Source]map[int64]int32)
+	for _, c := range counts {
+		if countsBySource[c.Source] == nil {
+			countsBySource[c.Source] = make(map[int64]int32)
+		}
+		countsBySource[c.Source][c.Day.Unix()] = c.Count
+	}
+	latencyBySource := make(map[model.Source]float64, len(latencies))
+	for _, l := range latencies {
+		latencyBySource[l.Source] = l.Latency
+	}
+
+	infos := make([]*model.SourceInfo, len(sources))
+	for i, source := range sources {
+		meta := GetSourceMetadata(source)
+		info := &model.SourceInfo{
+			Source:      source,
+			Name:        meta.Name,
+			Homepage:    meta.Homepage,
+			Description: meta.Description,
+			Leaning:     meta.Leaning,
+		}
+		if meta.LogoURL != "" {
+			info.LogoURL = &meta.LogoURL
+		}
+
+		run, ok := runsBySource[source]
+		info.Health = feedHealth(run, ok, healthSince)
+		info.LastSuccessfulScrape = run.LastSuccess
+
+		info.ArticlesPerDay = make([]*model.DailyArticleCount, 0, sourceStatsDays)
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			info.ArticlesPerDay = append(info.ArticlesPerDay, &model.DailyArticleCount{
+				Date:  day,
+				Count: countsBySource[source][day.Unix()],
+			})
+		}
+
+		if latency, ok := latencyBySource[source]; ok {
+			info.AveragePublishLatency = &latency
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+// feedHealth rates a feed by its runs within the health window
+func feedHealth(stats scrapeRunStats, scraped bool, since time.Time) model.FeedHealth {
+	switch {
+	case !scraped:
+		return model.FeedHealthUnknown
+	case stats.LastSuccess == nil || stats.LastSuccess.Before(since):
+		return model.FeedHealthFailing
+	case stats.RecentFailures > 0:
+		return model.FeedHealthDegraded
+	default:
+		return model.FeedHealthHealthy
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"news-swipe/backend/graph/model"
+)
+
+// SourceMetadata describes an outlet beyond its enum value.
+type SourceMetadata struct {
+	Source    model.Source  `json:"source"`
+	Name      string        `json:"name"`
+	Leaning   model.Leaning `json:"leaning"`
+	Ownership string        `json:"ownership"`
+	Region    string        `json:"region"`
+	Homepage  string        `json:"homepage"`
+	LogoURL   string        `json:"logoUrl"`
+	// Description is a short English introduction to the outlet
+	Description string `json:"description"`
+}
+
+//go:embed data/sources.json
+var defaultSourceMetadata []byte
+
+var (
+	sourceMetadata     map[model.Source]SourceMetadata
+	sourceMetadataOnce sync.Once
+)
+
+// LoadSourceMetadata parses source metadata from the file named by
+// SOURCE_METADATA_PATH, falling back to the bundled defaults.
+func LoadSourceMetadata() (map[model.Source]SourceMetadata, error) {
+	path := os.Getenv("SOURCE_METADATA_PATH")
+	if path == "" {
+		return parseSourceMetadata(defaultSourceMetadata)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source metadata: %w", err)
+	}
+	return parseSourceMetadata(data)
+}
+
+func parseSourceMetadata(data []byte) (map[model.Source]SourceMetadata, error) {
+	var entries []SourceMetadata
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse source metadata: %w", err)
+	}
+
+	result := make(map[model.Source]SourceMetadata, len(entries))
+	for _, entry := range entries {
+		result[entry.Source] = entry
+	}
+	return result, nil
+}
+
+// GetSourceMetadata returns the metadata for a source. Sources without an
+// entry are treated as centrist.
+func GetSourceMetadata(source model.Source) SourceMetadata {
+	sourceMetadataOnce.Do(func() {
+		metadata, err := LoadSourceMetadata()
+		if err != nil {
+			Log(System, "Falling back to bundled source metadata", "error", err)
+			metadata, _ = parseSourceMetadata(defaultSourceMetadata)
+		}
+		sourceMetadata = metadata
+	})
+
+	if meta, ok := sourceMetadata[source]; ok {
+		return meta
+	}
+	return SourceMetadata{Source: source, Name: source.String(), Leaning: model.LeaningCenter}
+}
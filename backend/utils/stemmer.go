@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/pemistahl/lingua-go"
+)
+
+var umlautReplacer = strings.NewReplacer("ä", "a", "ö", "o", "ü", "u", "ß", "ss")
+
+// stem reduces a lowercase word to a light stem so inflected forms such as
+// "wahlen"/"wahl" or "elections"/"election" are counted together. The stems
+// are only used for grouping and never shown to readers.
+func stem(word string, lang lingua.Language) string {
+	if lang == lingua.German {
+		return stemGerman(word)
+	}
+	return stemEnglish(word)
+}
+
+// germanSuffixes are the inflection endings stemGerman strips, longest first
+var germanSuffixes = []string{"ern", "est", "em", "en", "er", "es", "e", "s"}
+
+// germanMinStem is the shortest stem left after stripping, so short words
+// such as "gast" or "haus" are not cut down to unrelated ones
+const germanMinStem = 4
+
+// stemGerman strips a single inflection ending like step 1 of the Snowball
+// German stemmer. Stripping repeatedly would merge unrelated words, such as
+// "gastes" and "gas".
+func stemGerman(word string) string {
+	word = umlautReplacer.Replace(word)
+
+	for _, suffix := range germanSuffixes {
+		stemmed, ok := strings.CutSuffix(word, suffix)
+		if !ok || len([]rune(stemmed)) < germanMinStem {
+			continue
+		}
+		// A plain s is only an ending after consonants that allow it, which
+		// keeps words like "prozess" intact
+		if suffix == "s" && !strings.ContainsAny(stemmed[len(stemmed)-1:], "bdfghklmnrt") {
+			continue
+		}
+		return stemmed
+	}
+	return word
+}
+
+// stemEnglish strips plural and common verb suffixes
+func stemEnglish(word string) string {
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		return word[:len(word)-3]
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ly") && len(word) > 4:
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+		return word[:len(word)-1]
+	}
+	return word
+}
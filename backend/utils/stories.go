@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type articleLink struct {
+	ArticleID       string
+	LinkedArticleID string
+}
+
+// unionFind is a disjoint-set forest over article IDs with path compression
+// and union by size.
+type unionFind struct {
+	parent map[string]string
+	size   map[string]int
+}
+
+func newUnionFind(capacity int) *unionFind {
+	return &unionFind{
+		parent: make(map[string]string, capacity),
+		size:   make(map[string]int, capacity),
+	}
+}
+
+func (u *unionFind) add(id string) {
+	if _, ok := u.parent[id]; !ok {
+		u.parent[id] = id
+		u.size[id] = 1
+	}
+}
+
+func (u *unionFind) find(id string) string {
+	root := id
+	for u.parent[root] != root {
+		root = u.parent[root]
+	}
+	for u.parent[id] != root {
+		next := u.parent[id]
+		u.parent[id] = root
+		id = next
+	}
+	return root
+}
+
+func (u *unionFind) union(a, b string) {
+	ra, rb := u.find(a), u.find(b)
+	if ra == rb {
+		return
+	}
+	if u.size[ra] < u.size[rb] {
+		ra, rb = rb, ra
+	}
+	u.parent[rb] = ra
+	u.size[ra] += u.size[rb]
+}
+
+// StoryWindow bounds how far back stories are rebuilt. Articles published
+// within it are regrouped on every run together with the other articles of
+// stories updated within it; older stories are left as they are.
+const StoryWindow = 7 * 24 * time.Hour
+
+type storyData struct {
+	story    *model.Story
+	articles []string
+}
+
+// BuildStories groups linked articles into stories by computing the connected
+// components of the article link graph within StoryWindow. Story IDs are kept
+// stable between runs by reusing the ID most members were assigned to
+// previously.
+func BuildStories(db *gorm.DB) error {
+	cutoff := time.Now().Add(-StoryWindow)
+
+	var articles []model.Article
+	if err := storyScope(db, cutoff).Select("id, title, source, published_at, story_id").Find(&articles).Error; err != nil {
+		return err
+	}
+	if len(articles) == 0 {
+		return nil
+	}
+
+	var links []articleLink
+	if err := db.Table("article_links").
+		Select("article_links.article_id, article_links.linked_article_id").
+		Joins("JOIN articles ON articles.id = article_links.article_id").
+		Where("articles.published_at >= ? OR articles.story_id IN (?)", cutoff, activeStories(db, cutoff)).
+		Scan(&links).Error; err != nil {
+		return err
+	}
+
+	var previous []model.Story
+	if err := db.Select("id, headline, article_count, last_updated").Where("last_updated >= ?", cutoff).Find(&previous).Error; err != nil {
+		return err
+	}
+
+	stories := computeStories(articles, links)
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return persistStoriesInTx(tx, stories, cutoff)
+	}); err != nil {
+		return err
+	}
+
+	PublishStoriesUpdated(context.Background(), changedStories(previous, stories))
+	return nil
+}
+
+// activeStories selects the IDs of stories updated since cutoff
+func activeStories(db *gorm.DB, cutoff time.Time) *gorm.DB {
+	return db.Model(&model.Story{}).Select("id").Where("last_updated >= ?", cutoff)
+}
+
+// storyScope selects the articles a rebuild regroups: those published since
+// cutoff and the other members of their active stories
+func storyScope(db *gorm.DB, cutoff time.Time) *gorm.DB {
+	return db.Model(&model.Article{}).Where("published_at >= ? OR story_id IN (?)", cutoff, activeStories(db, cutoff))
+}
+
+// changedStories returns the IDs of stories that are new or whose headline,
+// size or last update differ from the previous run.
+func changedStories(previous []model.Story, stories []*storyData) []string {
+	before := make(map[string]model.Story, len(previous))
+	for _, s := range previous {
+		before[s.ID] = s
+	}
+
+	changed := make([]string, 0)
+	for _, s := range stories {
+		old, ok := before[s.story.ID]
+		if !ok || old.Headline != s.story.Headline || old.ArticleCount != s.story.ArticleCount ||
+			!old.LastUpdated.Equal(s.story.LastUpdated) {
+			changed = append(changed, s.story.ID)
+		}
+	}
+	return changed
+}
+
+func computeStories(articles []model.Article, links []articleLink) []*storyData {
+	byID := make(map[string]*model.Article, len(articles))
+	uf := newUnionFind(len(articles))
+	for i := range articles {
+		byID[articles[i].ID] = &articles[i]
+		uf.add(articles[i].ID)
+	}
+
+	degree := make(map[string]int, len(articles))
+	for _, l := range links {
+		if byID[l.ArticleID] == nil || byID[l.LinkedArticleID] == nil || l.ArticleID == l.LinkedArticleID {
+			continue
+		}
+		uf.union(l.ArticleID, l.LinkedArticleID)
+		degree[l.ArticleID]++
+		degree[l.LinkedArticleID]++
+	}
+
+	components := make(map[string][]string)
+	for id := range byID {
+		root := uf.find(id)
+		components[root] = append(components[root], id)
+	}
+
+	groups := make([][]string, 0, len(components))
+	for _, members := range components {
+		if len(members) < 2 {
+			continue
+		}
+		sort.Strings(members)
+		groups = append(groups, members)
+	}
+
+	// Larger stories claim their previous ID first so that a split keeps the
+	// existing ID on the bigger half.
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i]) != len(groups[j]) {
+			return len(groups[i]) > len(groups[j])
+		}
+		return groups[i][0] < groups[j][0]
+	})
+
+	claimed := make(map[string]bool, len(groups))
+	result := make([]*storyData, 0, len(groups))
+
+	for _, members := range groups {
+		id := pickStoryID(members, byID, claimed)
+		claimed[id] = true
+
+		story := &model.Story{
+			GormModel:    model.GormModel{ID: id},
+			ArticleCount: int32(len(members)),
+		}
+
+		var representative *model.Article
+		sourceSet := make(map[string]bool)
+		for _, artID := range members {
+			a := byID[artID]
+			sourceSet[a.Source.String()] = true
+
+			if story.FirstSeen.IsZero() || a.PublishedAt.Before(story.FirstSeen) {
+				story.FirstSeen = a.PublishedAt
+			}
+			if a.PublishedAt.After(story.LastUpdated) {
+				story.LastUpdated = a.PublishedAt
+			}
+			if representative == nil || isBetterRepresentative(a, representative, degree) {
+				representative = a
+			}
+		}
+		story.Headline = representative.Title
+
+		sources := make([]string, 0, len(sourceSet))
+		for s := range sourceSet {
+			sources = append(sources, s)
+		}
+		sort.Strings(sources)
+		story.Sources = sources
+
+		result = append(result, &storyData{story: story, articles: members})
+	}
+
+	return result
+}
+
+// pickStoryID returns the previous story ID shared by most members that has
+// not been claimed by a larger story yet, or a fresh ID.
+func pickStoryID(members []string, byID map[string]*model.Article, claimed map[string]bool) string {
+	counts := make(map[string]int)
+	for _, artID := range members {
+		if sid := byID[artID].StoryID; sid != nil && *sid != "" && !claimed[*sid] {
+			counts[*sid]++
+		}
+	}
+
+	best, bestCount := "", 0
+	for sid, c := range counts {
+		if c > bestCount || (c == bestCount && sid < best) {
+			best, bestCount = sid, c
+		}
+	}
+	if best == "" {
+		return uuid.NewString()
+	}
+	return best
+}
+
+// isBetterRepresentative prefers the most linked article, then the earliest.
+func isBetterRepresentative(a, current *model.Article, degree map[string]int) bool {
+	if degree[a.ID] != degree[current.ID] {
+		return degree[a.ID] > degree[current.ID]
+	}
+	if !a.PublishedAt.Equal(current.PublishedAt) {
+		return a.PublishedAt.Before(current.PublishedAt)
+	}
+	return a.ID < current.ID
+}
+
+// persistStoriesInTx replaces the active stories. Stories that no longer
+// exist are deleted for good, since they are rebuilt every run.
+func persistStoriesInTx(tx *gorm.DB, stories []*storyData, cutoff time.Time) error {
+	if err := storyScope(tx, cutoff).
+		Where("story_id IS NOT NULL").
+		Update("story_id", nil).Error; err != nil {
+		return err
+	}
+
+	if len(stories) == 0 {
+		return tx.Unscoped().Where("last_updated >= ?", cutoff).Delete(&model.Story{}).Error
+	}
+
+	now := time.Now()
+	storyList := make([]*model.Story, len(stories))
+	ids := make([]string, len(stories))
+	for i, s := range stories {
+		s.story.UpdatedAt = now
+		storyList[i] = s.story
+		ids[i] = s.story.ID
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"headline", "first_seen", "last_updated", "article_count", "sources", "updated_at", "deleted_at",
+		}),
+	}).CreateInBatches(storyList, 100).Error; err != nil {
+		return err
+	}
+
+	for _, s := range stories {
+		if err := tx.Model(&model.Article{}).
+			Where("id IN ?", s.articles).
+			Update("story_id", s.story.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Unscoped().Where("id NOT IN ? AND last_updated >= ?", ids, cutoff).Delete(&model.Story{}).Error; err != nil {
+		return err
+	}
+
+	Log(Database, "Stories rebuilt", "count", len(stories))
+	return nil
+}
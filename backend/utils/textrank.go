@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"math"
+	"sort"
+
+	"news-swipe/backend/graph/model"
+)
+
+// TextRankExtractor ranks stemmed words by PageRank over their co-occurrence
+// graph and joins adjacent top-ranked words into keyphrases.
+type TextRankExtractor struct {
+	// Window is the number of positions within which two words are connected
+	Window     int
+	Damping    float64
+	Iterations int
+	// MaxWords caps the length of a keyphrase
+	MaxWords int
+}
+
+func DefaultTextRankExtractor() TextRankExtractor {
+	return TextRankExtractor{
+		Window:     3,
+		Damping:    0.85,
+		Iterations: 50,
+		MaxWords:   3,
+	}
+}
+
+func (e TextRankExtractor) Name() string { return "textrank" }
+
+func (e TextRankExtractor) Extract(cluster []model.Article, max int) []ScoredKeyword {
+	streams := tokenStreams(cluster)
+
+	graph := make(map[string]map[string]float64)
+	for _, stream := range streams {
+		for i, t := range stream {
+			if !t.candidate {
+				continue
+			}
+			if graph[t.stem] == nil {
+				graph[t.stem] = make(map[string]float64)
+			}
+			for j := i + 1; j < len(stream) && j < i+e.Window; j++ {
+				u := stream[j]
+				if !u.candidate || u.stem == t.stem {
+					continue
+				}
+				if graph[u.stem] == nil {
+					graph[u.stem] = make(map[string]float64)
+				}
+				graph[t.stem][u.stem]++
+				graph[u.stem][t.stem]++
+			}
+		}
+	}
+	if len(graph) == 0 {
+		return nil
+	}
+
+	ranks := e.pageRank(graph)
+
+	// Only the top third of words, but at least max, may form keyphrases
+	ordered := make([]string, 0, len(ranks))
+	for w := range ranks {
+		ordered = append(ordered, w)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ranks[ordered[i]] != ranks[ordered[j]] {
+			return ranks[ordered[i]] > ranks[ordered[j]]
+		}
+		return ordered[i] < ordered[j]
+	})
+	keep := len(ordered) / 3
+	if keep < max {
+		keep = max
+	}
+	if keep > len(ordered) {
+		keep = len(ordered)
+	}
+	top := make(map[string]bool, keep)
+	for _, w := range ordered[:keep] {
+		top[w] = true
+	}
+
+	forms := make(phraseForms)
+	scores := make(map[string]float64)
+	for _, stream := range streams {
+		for i := 0; i < len(stream); {
+			if !stream[i].candidate || !top[stream[i].stem] {
+				i++
+				continue
+			}
+			end := i + 1
+			for end < len(stream) && end-i < e.MaxWords && !stream[end-1].boundary &&
+				stream[end].candidate && top[stream[end].stem] {
+				end++
+			}
+
+			score := 0.0
+			for _, t := range stream[i:end] {
+				score += ranks[t.stem]
+			}
+			key := forms.add(stream[i:end])
+			if score > scores[key] {
+				scores[key] = score
+			}
+			i = end
+		}
+	}
+
+	return topPhrases(scores, forms, max)
+}
+
+func (e TextRankExtractor) pageRank(graph map[string]map[string]float64) map[string]float64 {
+	outWeight := make(map[string]float64, len(graph))
+	ranks := make(map[string]float64, len(graph))
+	for v, edges := range graph {
+		ranks[v] = 1.0
+		for _, w := range edges {
+			outWeight[v] += w
+		}
+	}
+
+	for iter := 0; iter < e.Iterations; iter++ {
+		next := make(map[string]float64, len(graph))
+		delta := 0.0
+		for v, edges := range graph {
+			sum := 0.0
+			for u, w := range edges {
+				if outWeight[u] > 0 {
+					sum += w / outWeight[u] * ranks[u]
+				}
+			}
+			next[v] = (1 - e.Damping) + e.Damping*sum
+			delta += math.Abs(next[v] - ranks[v])
+		}
+		ranks = next
+		if delta < 1e-4 {
+			break
+		}
+	}
+	return ranks
+}
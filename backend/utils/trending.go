@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+)
+
+// The trending score ranks articles like Hacker News ranks stories:
+//
+//	score = (recent opens + outletWeight * other outlets + 1) / (age in hours + 2)^gravity
+//
+// Recent opens are the opens of the last trendingViewWindow, other outlets
+// the number of other sources among the linked articles. The gravity makes
+// every article sink with age regardless of its all-time views.
+const (
+	// TrendingGravity is how quickly articles sink with age
+	TrendingGravity = 1.8
+	// trendingOutletWeight is how many opens covering the story in another
+	// outlet is worth
+	trendingOutletWeight = 5.0
+	// trendingViewWindow is how far back opens count as recent
+	trendingViewWindow = 24 * time.Hour
+	// viewBucketRetention is how long hourly open counts are kept
+	viewBucketRetention = 48 * time.Hour
+	// trendingMaxAge is the age beyond which articles no longer trend, which
+	// keeps each run from rescoring the whole archive
+	trendingMaxAge = 3 * 24 * time.Hour
+)
+
+// UpdateTrendingScores recomputes the trending score of the articles
+// published within trendingMaxAge. Ages change continuously, so their scores
+// are refreshed on each run; older articles are reset to zero once.
+func UpdateTrendingScores(db *gorm.DB) error {
+	now := time.Now()
+	cutoff := now.Add(-trendingMaxAge)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			WITH recent AS (
+				SELECT article_id, SUM(opens) AS opens
+				FROM article_view_buckets
+				WHERE bucket >= ?
+				GROUP BY article_id
+			), links AS (
+				SELECT article_id, linked_article_id AS other_id FROM article_links
+				UNION
+				SELECT linked_article_id, article_id FROM article_links
+			), outlets AS (
+				SELECT l.article_id, COUNT(DISTINCT o.source) AS outlets
+				FROM links l
+				JOIN articles a ON a.id = l.article_id AND a.published_at >= ?
+				JOIN articles o ON o.id = l.other_id AND o.deleted_at IS NULL AND o.source <> a.source
+				GROUP BY l.article_id
+			)
+			UPDATE articles
+			SET trending_score = s.score
+			FROM (
+				SELECT a.id,
+					(COALESCE(r.opens, 0) + ?::float8 * COALESCE(o.outlets, 0) + 1) /
+						power(GREATEST(EXTRACT(EPOCH FROM (?::timestamptz - a.published_at))::float8 / 3600, 0) + 2, ?::float8) AS score
+				FROM articles a
+				LEFT JOIN recent r ON r.article_id = a.id
+				LEFT JOIN outlets o ON o.article_id = a.id
+				WHERE a.deleted_at IS NULL AND a.published_at >= ?
+			) s
+			WHERE articles.id = s.id
+		`, now.Add(-trendingViewWindow), cutoff, trendingOutletWeight, now, TrendingGravity, cutoff).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`UPDATE articles SET trending_score = 0 WHERE published_at < ? AND trending_score <> 0`, cutoff).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("bucket < ?", now.Add(-viewBucketRetention)).
+			Delete(&model.ArticleViewBucket{}).Error
+	})
+}
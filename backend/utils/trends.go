@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// TrendInterval is the resolution of the keyword time series
+	TrendInterval = time.Hour
+	// TrendBaselineWindows is how many preceding windows form the baseline a
+	// keyword's current activity is compared against
+	TrendBaselineWindows = 7
+	// trendRefreshWindow is how far back bucket counts are recomputed on each
+	// run, covering late-arriving articles
+	trendRefreshWindow = 48 * time.Hour
+	// trendRetention is how long trend points are kept. It covers the current
+	// and baseline windows of the longest trend window, so velocities never
+	// read deleted points.
+	trendRetention = (TrendBaselineWindows+1)*7*24*time.Hour + trendRefreshWindow
+)
+
+// TrendWindowDuration returns the length of a trend window
+func TrendWindowDuration(window model.TrendWindow) time.Duration {
+	switch window {
+	case model.TrendWindowHour:
+		return time.Hour
+	case model.TrendWindowWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// RecordKeywordTrends stores how many member articles of each active keyword
+// were published per TrendInterval, recomputing the recent buckets.
+func RecordKeywordTrends(db *gorm.DB) error {
+	now := time.Now()
+	since := now.Add(-trendRefreshWindow).Truncate(TrendInterval)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO keyword_trend_points (key_words_id, bucket, article_count)
+			SELECT ak.key_words_id, date_trunc('hour', a.published_at), COUNT(*)
+			FROM article_keywords ak
+			JOIN articles a ON a.id = ak.article_id AND a.deleted_at IS NULL
+			JOIN key_words k ON k.id = ak.key_words_id AND k.expired_at IS NULL AND k.deleted_at IS NULL
+			WHERE a.published_at >= ?
+			GROUP BY ak.key_words_id, date_trunc('hour', a.published_at)
+			ON CONFLICT (key_words_id, bucket) DO UPDATE SET article_count = excluded.article_count
+		`, since).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("bucket < ?", now.Add(-trendRetention)).
+			Delete(&model.KeywordTrendPoint{}).Error
+	})
+}
+
+// KeywordHistory returns the article counts of a keyword per TrendInterval
+// over the given window, oldest first. Empty intervals are included so the
+// series can be drawn directly.
+func KeywordHistory(db *gorm.DB, keywordID string, window time.Duration) ([]*model.KeywordTrendPoint, error) {
+	end := time.Now().Truncate(TrendInterval)
+	start := end.Add(-window + TrendInterval)
+
+	var stored []model.KeywordTrendPoint
+	if err := db.Where("key_words_id = ? AND bucket >= ?", keywordID, start).
+		Order("bucket ASC").
+		Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]int32, len(stored))
+	for _, p := range stored {
+		counts[p.Bucket.Unix()] = p.ArticleCount
+	}
+
+	points := make([]*model.KeywordTrendPoint, 0, int(window/TrendInterval))
+	for b := start; !b.After(end); b = b.Add(TrendInterval) {
+		points = append(points, &model.KeywordTrendPoint{
+			KeyWordsID:   keywordID,
+			Bucket:       b,
+			ArticleCount: counts[b.Unix()],
+		})
+	}
+	return points, nil
+}
+
+// KeywordVelocity describes how a keyword's activity in the current window
+// compares with its trailing baseline.
+type KeywordVelocity struct {
+	KeyWordsID   string
+	ArticleCount int
+	// ZScore of the current window against the baseline windows
+	ZScore float64
+	// Acceleration is the change in articles from the previous window
+	Acceleration int
+}
+
+// KeywordVelocities scores every keyword of a language with articles in the
+// current window and returns them ordered by z-score, highest first.
+func KeywordVelocities(db *gorm.DB, lang model.Language, window time.Duration, limit int) ([]KeywordVelocity, error) {
+	now := time.Now()
+	since := now.Add(-window * time.Duration(TrendBaselineWindows+1))
+
+	var points []model.KeywordTrendPoint
+	if err := db.Table("keyword_trend_points p").
+		Select("p.key_words_id, p.bucket, p.article_count").
+		Joins("JOIN key_words k ON k.id = p.key_words_id AND k.expired_at IS NULL AND k.deleted_at IS NULL AND k.language = ?", lang).
+		Where("p.bucket >= ?", since).
+		Scan(&points).Error; err != nil {
+		return nil, err
+	}
+
+	// windows[id][0] is the current window, [1] the one before and so on
+	windows := make(map[string][]int)
+	for _, p := range points {
+		idx := int(now.Sub(p.Bucket) / window)
+		if idx < 0 || idx > TrendBaselineWindows {
+			continue
+		}
+		if windows[p.KeyWordsID] == nil {
+			windows[p.KeyWordsID] = make([]int, TrendBaselineWindows+1)
+		}
+		windows[p.KeyWordsID][idx] += int(p.ArticleCount)
+	}
+
+	velocities := make([]KeywordVelocity, 0, len(windows))
+	for id, counts := range windows {
+		if counts[0] == 0 {
+			continue
+		}
+		velocities = append(velocities, KeywordVelocity{
+			KeyWordsID:   id,
+			ArticleCount: counts[0],
+			ZScore:       zScore(counts[0], counts[1:]),
+			Acceleration: counts[0] - counts[1],
+		})
+	}
+
+	sort.Slice(velocities, func(i, j int) bool {
+		if velocities[i].ZScore != velocities[j].ZScore {
+			return velocities[i].ZScore > velocities[j].ZScore
+		}
+		if velocities[i].ArticleCount != velocities[j].ArticleCount {
+			return velocities[i].ArticleCount > velocities[j].ArticleCount
+		}
+		return velocities[i].KeyWordsID < velocities[j].KeyWordsID
+	})
+
+	if limit > 0 && len(velocities) > limit {
+		velocities = velocities[:limit]
+	}
+	return velocities, nil
+}
+
+// zScore compares current against the baseline. The standard deviation is
+// floored at one article so a quiet keyword gaining a single article does not
+// dominate the ranking.
+func zScore(current int, baseline []int) float64 {
+	if len(baseline) == 0 {
+		return 0.0
+	}
+
+	var sum float64
+	for _, c := range baseline {
+		sum += float64(c)
+	}
+	mean := sum / float64(len(baseline))
+
+	var variance float64
+	for _, c := range baseline {
+		d := float64(c) - mean
+		variance += d * d
+	}
+	stddev := math.Sqrt(variance / float64(len(baseline)))
+	if stddev < 1 {
+		stddev = 1
+	}
+
+	return (float64(current) - mean) / stddev
+}
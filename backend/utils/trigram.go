@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// TrigramCandidateThreshold is the pg_trgm similarity a title or
+	// description must reach for the GIN index to return the row as a candidate
+	TrigramCandidateThreshold = 0.2
+	// TrigramLinkThreshold is the minimum weighted trigram score for an on-demand
+	// match to be returned or stored in article_links
+	TrigramLinkThreshold = 0.3
+	// trigramWindow limits candidates to articles published around the same time
+	trigramWindow = 24 * time.Hour
+)
+
+// trigramQuery scores candidates by weighted pg_trgm similarity of title and
+// description. The % operators let Postgres use the GIN trigram indexes.
+const trigramQuery = `
+	SELECT * FROM (
+		SELECT
+			a.*,
+			0.6 * similarity(a.title, @title) + 0.4 * similarity(a.description, @description) AS score
+		FROM articles a
+		WHERE a.id <> @id
+			AND a.deleted_at IS NULL
+			AND a.language = @language
+			AND a.published_at BETWEEN @from AND @to
+			AND (a.title % @title OR a.description % @description)
+	) scored
+	WHERE scored.score >= @threshold
+	ORDER BY scored.score DESC
+	LIMIT @limit`
+
+type scoredRow struct {
+	model.Article
+	Score float64
+}
+
+// FindSimilarArticles returns articles in the language of article whose
+// trigram similarity to it is above TrigramLinkThreshold, best match first.
+// It only reads, so it is safe to call from public queries.
+func FindSimilarArticles(db *gorm.DB, article model.Article, limit int) ([]*model.ScoredArticle, error) {
+	var scored []*model.ScoredArticle
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		scored, err = findSimilarArticlesInTx(tx, article, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scored, nil
+}
+
+func findSimilarArticlesInTx(tx *gorm.DB, article model.Article, limit int) ([]*model.ScoredArticle, error) {
+	// SET LOCAL needs a transaction and does not accept bind parameters
+	if err := tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.similarity_threshold = %g", TrigramCandidateThreshold)).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []scoredRow
+	if err := tx.Raw(trigramQuery, map[string]any{
+		"id":          article.ID,
+		"title":       article.Title,
+		"description": article.Description,
+		"language":    article.Language,
+		"from":        article.PublishedAt.Add(-trigramWindow),
+		"to":          article.PublishedAt.Add(trigramWindow),
+		"threshold":   TrigramLinkThreshold,
+		"limit":       limit,
+	}).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	scored := make([]*model.ScoredArticle, 0, len(rows))
+	for i := range rows {
+		scored = append(scored, &model.ScoredArticle{
+			Article: &rows[i].Article,
+			Score:   rows[i].Score,
+		})
+	}
+	return scored, nil
+}
+
+// LinkSimilarArticles finds trigram matches for an article and stores them as
+// links in a single transaction. Only matches above TrigramLinkThreshold are
+// returned and persisted. Links feed stories and trending, so this is only
+// run on behalf of admins.
+func LinkSimilarArticles(db *gorm.DB, article model.Article, limit int) ([]*model.ScoredArticle, error) {
+	var scored []*model.ScoredArticle
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		scored, err = findSimilarArticlesInTx(tx, article, limit)
+		if err != nil || len(scored) == 0 {
+			return err
+		}
+
+		rows := make([]map[string]any, 0, len(scored))
+		for _, s := range scored {
+			rows = append(rows, map[string]any{
+				"article_id":        article.ID,
+				"linked_article_id": s.Article.ID,
+			})
+		}
+		return tx.Table("article_links").
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scored, nil
+}
@@ -0,0 +1,316 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Articles count two kinds of views: impressions whenever they are returned
+// in a listing and opens reported through the recordView mutation. Both are
+// buffered in memory and added to the articles table in batches, so reads
+// never wait on a write.
+
+// ViewFlushInterval is how often buffered view counts are written
+const ViewFlushInterval = 10 * time.Second
+
+const (
+	// defaultViewDedupWindow is how long repeated opens of an article by the
+	// same client count only once
+	defaultViewDedupWindow = 30 * time.Minute
+	viewDedupPrefix        = "veritas:view:"
+	// viewClientPrefix keys when a client ID was first seen. IDs seen for
+	// less than the dedup window are deduplicated by address as well, so
+	// rotating the ID does not count an open again.
+	viewClientPrefix = "veritas:view-client:"
+	viewClientTTL    = 30 * 24 * time.Hour
+	// viewFlushBatch bounds the number of articles updated per statement
+	viewFlushBatch = 500
+)
+
+type viewCounts struct {
+	impressions int32
+	opens       int32
+}
+
+// ViewCounter buffers view counts until the next flush
+type ViewCounter struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[string]viewCounts
+}
+
+// Views is the counter shared by the resolvers and the flush worker. main
+// replaces it once the environment is loaded to apply VIEW_DEDUP_WINDOW.
+var Views = NewViewCounter(defaultViewDedupWindow)
+
+func NewViewCounter(window time.Duration) *ViewCounter {
+	return &ViewCounter{window: window, pending: make(map[string]viewCounts)}
+}
+
+// ViewDedupWindow reads VIEW_DEDUP_WINDOW, a Go duration such as "1h"
+func ViewDedupWindow() time.Duration {
+	if raw := os.Getenv("VIEW_DEDUP_WINDOW"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			return d
+		}
+		Log(System, "Ignoring invalid VIEW_DEDUP_WINDOW", "value", raw)
+	}
+	return defaultViewDedupWindow
+}
+
+// RecordImpressions counts an impression for each listed article and notes
+// it in the impression log of ctx, if any.
+func (c *ViewCounter) RecordImpressions(ctx context.Context, articles []*model.Article) {
+	if len(articles) == 0 {
+		return
+	}
+
+	ids := make([]string, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+	if log, ok := ctx.Value(impressionLogKey{}).(*ImpressionLog); ok {
+		log.add(ids)
+	}
+	c.RecordImpressionIDs(ids)
+}
+
+// RecordImpressionIDs counts an impression for each article ID, such as the
+// articles of a response served from the cache.
+func (c *ViewCounter) RecordImpressionIDs(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	for _, id := range ids {
+		counts := c.pending[id]
+		counts.impressions++
+		c.pending[id] = counts
+	}
+	c.mu.Unlock()
+
+	ArticleViewsTotal.WithLabelValues("impression").Add(float64(len(ids)))
+}
+
+type impressionLogKey struct{}
+
+// ImpressionLog collects the articles listed while a response is resolved,
+// so they can be counted again whenever a cached copy of it is served.
+type ImpressionLog struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+// WithImpressionLog returns a context whose impressions are noted in the
+// returned log.
+func WithImpressionLog(ctx context.Context) (context.Context, *ImpressionLog) {
+	log := &ImpressionLog{}
+	return context.WithValue(ctx, impressionLogKey{}, log), log
+}
+
+func (l *ImpressionLog) add(ids []string) {
+	l.mu.Lock()
+	l.ids = append(l.ids, ids...)
+	l.mu.Unlock()
+}
+
+// IDs returns the article IDs noted so far, once per impression
+func (l *ImpressionLog) IDs() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.ids...)
+}
+
+// ViewClient identifies who opened an article by the ID the app sends and
+// the address the request came from
+type ViewClient struct {
+	ID string
+	IP string
+}
+
+// RecordOpen counts an open of an article unless the client opened it
+// within the dedup window. It reports whether the open was counted. Without
+// Redis every open is counted.
+func (c *ViewCounter) RecordOpen(ctx context.Context, client ViewClient, articleID string) bool {
+	if RedisClient != nil {
+		duplicate := false
+		for _, id := range c.dedupClients(ctx, client) {
+			key := viewDedupPrefix + hashClient(id) + ":" + articleID
+			first, err := RedisClient.SetNX(ctx, key, 1, c.window).Result()
+			if err != nil {
+				// Prefer an occasional double count over losing the view
+				Log(GraphQL, "View deduplication failed", "error", err)
+			} else if !first {
+				duplicate = true
+			}
+		}
+		if duplicate {
+			ArticleViewsTotal.WithLabelValues("duplicate_open").Inc()
+			return false
+		}
+	}
+
+	c.mu.Lock()
+	counts := c.pending[articleID]
+	counts.opens++
+	c.pending[articleID] = counts
+	c.mu.Unlock()
+
+	ArticleViewsTotal.WithLabelValues("open").Inc()
+	return true
+}
+
+// dedupClients returns the identities an open is deduplicated by: the
+// address without a client ID, the ID once it is established and both while
+// it is new.
+func (c *ViewCounter) dedupClients(ctx context.Context, client ViewClient) []string {
+	ids := make([]string, 0, 2)
+	if client.ID != "" {
+		ids = append(ids, "id:"+client.ID)
+		if c.establishedClient(ctx, client.ID) {
+			return ids
+		}
+	}
+	if client.IP != "" {
+		ids = append(ids, "ip:"+client.IP)
+	}
+	return ids
+}
+
+// establishedClient reports whether a client ID was first seen at least one
+// dedup window ago
+func (c *ViewCounter) establishedClient(ctx context.Context, id string) bool {
+	key := viewClientPrefix + hashClient(id)
+	now := time.Now()
+	first, err := RedisClient.SetNX(ctx, key, now.Unix(), viewClientTTL).Result()
+	if err != nil || first {
+		return false
+	}
+
+	seen, err := RedisClient.Get(ctx, key).Int64()
+	if err != nil {
+		return false
+	}
+	return now.Sub(time.Unix(seen, 0)) >= c.window
+}
+
+// hashClient keeps client addresses and IDs out of Redis
+func hashClient(client string) string {
+	sum := sha256.Sum256([]byte(client))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Flush adds the buffered counts to the articles table. Counts that could
+// not be written are kept for the next flush.
+func (c *ViewCounter) Flush(ctx context.Context, db *gorm.DB) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[string]viewCounts)
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(pending))
+	for id := range pending {
+		ids = append(ids, id)
+	}
+
+	for start := 0; start < len(ids); start += viewFlushBatch {
+		end := start + viewFlushBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := flushViewBatch(ctx, db, ids[start:end], pending); err != nil {
+			c.restore(ids[start:], pending)
+			return err
+		}
+	}
+	return nil
+}
+
+// flushViewBatch adds the counts to the articles and the opens to the
+// current hourly bucket used by the trending score
+func flushViewBatch(ctx context.Context, db *gorm.DB, ids []string, pending map[string]viewCounts) error {
+	bucket := time.Now().Truncate(TrendInterval)
+
+	rows := make([]string, len(ids))
+	args := make([]any, 0, len(ids)*3)
+	buckets := make([]model.ArticleViewBucket, 0, len(ids))
+	for i, id := range ids {
+		rows[i] = "(?, ?::integer, ?::integer)"
+		args = append(args, id, pending[id].impressions, pending[id].opens)
+		if pending[id].opens > 0 {
+			buckets = append(buckets, model.ArticleViewBucket{ArticleID: id, Bucket: bucket, Opens: pending[id].opens})
+		}
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			UPDATE articles
+			SET impressions = articles.impressions + v.impressions,
+				views = articles.views + v.opens
+			FROM (VALUES `+strings.Join(rows, ", ")+`) AS v(id, impressions, opens)
+			WHERE articles.id = v.id
+		`, args...).Error; err != nil {
+			return err
+		}
+		if len(buckets) == 0 {
+			return nil
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "article_id"}, {Name: "bucket"}},
+			DoUpdates: clause.Assignments(map[string]any{"opens": gorm.Expr("article_view_buckets.opens + excluded.opens")}),
+		}).Create(&buckets).Error
+	})
+}
+
+// restore merges unwritten counts back into the buffer
+func (c *ViewCounter) restore(ids []string, counts map[string]viewCounts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range ids {
+		current := c.pending[id]
+		current.impressions += counts[id].impressions
+		current.opens += counts[id].opens
+		c.pending[id] = current
+	}
+}
+
+// Run flushes the buffered counts every interval until ctx is done, then
+// flushes once more so no counts are lost on shutdown.
+func (c *ViewCounter) Run(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := c.Flush(flushCtx, db); err != nil {
+				Log(Database, "Final view flush failed", "error", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx, db); err != nil {
+				Log(Database, "View flush failed", "error", err)
+			}
+		}
+	}
+}
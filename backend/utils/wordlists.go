@@ -0,0 +1,362 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"news-swipe/backend/graph/model"
+
+	"github.com/pemistahl/lingua-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Word lists are plain text files named <kind>_<language>.txt with one word
+// per line; lines starting with # are comments. Files in WORDLISTS_PATH
+// replace the bundled file of the same name, and entries added through the
+// API are merged on top.
+
+//go:embed data/wordlists/*.txt
+var defaultWordLists embed.FS
+
+// WordListReloadInterval is how often the word list files and entries are
+// checked for changes.
+const WordListReloadInterval = time.Minute
+
+var ErrInvalidWordList = errors.New("invalid word list")
+
+// wordLists is an immutable snapshot of all lists. Reloads swap the whole
+// snapshot so readers never see a half-loaded list.
+type wordLists struct {
+	lists map[model.WordListKind]map[lingua.Language]map[string]bool
+	// merged holds the union over all languages, used for text of unknown
+	// language
+	merged map[model.WordListKind]map[string]bool
+}
+
+var (
+	currentWordLists atomic.Pointer[wordLists]
+	wordListsOnce    sync.Once
+	wordListsMu      sync.Mutex
+)
+
+func parseWordListFileName(name string) (model.WordListKind, lingua.Language, bool) {
+	base, ok := strings.CutSuffix(name, ".txt")
+	if !ok {
+		return "", lingua.Unknown, false
+	}
+	kindName, code, ok := strings.Cut(base, "_")
+	if !ok {
+		return "", lingua.Unknown, false
+	}
+	kind := model.WordListKind(strings.ToUpper(kindName))
+	lang := lingua.GetLanguageFromIsoCode639_1(lingua.GetIsoCode639_1FromValue(code))
+	if !kind.IsValid() || lang == lingua.Unknown {
+		return "", lingua.Unknown, false
+	}
+	return kind, lang, true
+}
+
+func parseWordList(data []byte) []string {
+	var words []string
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, normalizeWordListEntry(line))
+	}
+	return words
+}
+
+func normalizeWordListEntry(word string) string {
+	return strings.ToLower(strings.TrimSpace(word))
+}
+
+// readWordListFiles returns the bundled lists overridden by the files in dir
+func readWordListFiles(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	entries, err := fs.ReadDir(defaultWordLists, "data/wordlists")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		data, err := defaultWordLists.ReadFile("data/wordlists/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		files[e.Name()] = data
+	}
+
+	if dir == "" {
+		return files, nil
+	}
+	overrides, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read word lists: %w", err)
+	}
+	for _, e := range overrides {
+		if e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read word list %s: %w", e.Name(), err)
+		}
+		files[e.Name()] = data
+	}
+	return files, nil
+}
+
+// loadWordLists builds a snapshot from the files and, when db is set, the
+// entries stored in the database.
+func loadWordLists(db *gorm.DB, dir string) (*wordLists, error) {
+	files, err := readWordListFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	wl := &wordLists{
+		lists:  make(map[model.WordListKind]map[lingua.Language]map[string]bool),
+		merged: make(map[model.WordListKind]map[string]bool),
+	}
+	add := func(kind model.WordListKind, lang lingua.Language, word string) {
+		if word == "" {
+			return
+		}
+		if wl.lists[kind] == nil {
+			wl.lists[kind] = make(map[lingua.Language]map[string]bool)
+			wl.merged[kind] = make(map[string]bool)
+		}
+		if wl.lists[kind][lang] == nil {
+			wl.lists[kind][lang] = make(map[string]bool)
+		}
+		wl.lists[kind][lang][word] = true
+		wl.merged[kind][word] = true
+	}
+
+	for name, data := range files {
+		kind, lang, ok := parseWordListFileName(name)
+		if !ok {
+			Log(System, "Ignoring word list with unexpected name", "file", name)
+			continue
+		}
+		for _, word := range parseWordList(data) {
+			add(kind, lang, word)
+		}
+	}
+
+	if db != nil {
+		var entries []model.WordListEntry
+		if err := db.Find(&entries).Error; err != nil {
+			return nil, fmt.Errorf("failed to load word list entries: %w", err)
+		}
+		for _, e := range entries {
+			add(e.Kind, e.Language.ToLingua(), e.Word)
+		}
+	}
+	return wl, nil
+}
+
+func (wl *wordLists) equal(other *wordLists) bool {
+	if other == nil || len(wl.lists) != len(other.lists) {
+		return false
+	}
+	for kind, byLang := range wl.lists {
+		if len(byLang) != len(other.lists[kind]) {
+			return false
+		}
+		for lang, words := range byLang {
+			if !maps.Equal(words, other.lists[kind][lang]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ReloadWordLists rereads the word list files and stored entries. It reports
+// whether any list changed; on error the previous lists stay active.
+func ReloadWordLists(db *gorm.DB) (bool, error) {
+	wordListsMu.Lock()
+	defer wordListsMu.Unlock()
+
+	wl, err := loadWordLists(db, os.Getenv("WORDLISTS_PATH"))
+	if err != nil {
+		return false, err
+	}
+	if wl.equal(currentWordLists.Load()) {
+		return false, nil
+	}
+	currentWordLists.Store(wl)
+	return true, nil
+}
+
+// WatchWordLists reloads the word lists every interval until ctx is done, so
+// edited files and entries added on other instances take effect without a
+// restart.
+func WatchWordLists(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := ReloadWordLists(db)
+			if err != nil {
+				Log(System, "Word list reload failed", "error", err)
+			} else if changed {
+				Log(System, "Word lists reloaded")
+			}
+		}
+	}
+}
+
+// getWordLists returns the active lists. Commands that never call
+// ReloadWordLists get the file based lists only.
+func getWordLists() *wordLists {
+	if wl := currentWordLists.Load(); wl != nil {
+		return wl
+	}
+	wordListsOnce.Do(func() {
+		if _, err := ReloadWordLists(nil); err != nil {
+			Log(System, "Falling back to bundled word lists", "error", err)
+			wl, _ := loadWordLists(nil, "")
+			currentWordLists.CompareAndSwap(nil, wl)
+		}
+	})
+	return currentWordLists.Load()
+}
+
+func (wl *wordLists) words(kind model.WordListKind, lang lingua.Language) map[string]bool {
+	if lang == lingua.Unknown {
+		return wl.merged[kind]
+	}
+	return wl.lists[kind][lang]
+}
+
+// Stopwords returns the stopwords of a language. Text of unknown language is
+// filtered with every list.
+func Stopwords(lang lingua.Language) map[string]bool {
+	return getWordLists().words(model.WordListKindStopwords, lang)
+}
+
+// AllStopwords returns the stopwords of every language
+func AllStopwords() map[string]bool {
+	return getWordLists().merged[model.WordListKindStopwords]
+}
+
+// isBlockedPhrase reports whether any word of a phrase is blocked, so
+// "Liveblog Ukraine" is dropped along with "Liveblog".
+func isBlockedPhrase(phrase string, lang lingua.Language) bool {
+	blocked := getWordLists().words(model.WordListKindBlocklist, lang)
+	for _, w := range strings.Fields(strings.ToLower(phrase)) {
+		if blocked[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// WordList returns the sorted words of a list
+func WordList(kind model.WordListKind, lang model.Language) *model.WordList {
+	words := make([]string, 0)
+	for w := range getWordLists().lists[kind][lang.ToLingua()] {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return &model.WordList{Kind: kind, Language: lang, Words: words}
+}
+
+// AddWordListEntries stores words in a list and reloads the lists so they
+// apply from the next run on.
+func AddWordListEntries(db *gorm.DB, kind model.WordListKind, lang model.Language, words []string) (*model.WordList, error) {
+	if !kind.IsValid() || lang.ToLingua() == lingua.Unknown {
+		return nil, ErrInvalidWordList
+	}
+
+	entries := make([]model.WordListEntry, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for _, w := range words {
+		word := normalizeWordListEntry(w)
+		if word == "" || strings.ContainsFunc(word, isSpace) || seen[word] {
+			continue
+		}
+		seen[word] = true
+		entries = append(entries, model.WordListEntry{Kind: kind, Language: lang, Word: word})
+	}
+	if len(entries) == 0 {
+		return nil, ErrInvalidWordList
+	}
+
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error; err != nil {
+		return nil, err
+	}
+	if _, err := ReloadWordLists(db); err != nil {
+		return nil, err
+	}
+	return WordList(kind, lang), nil
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// tokenFilter applies the stopword list and blocklist of one language and
+// counts what it drops. Counts are kept locally and published by flush to
+// keep the metric off the per-token path.
+type tokenFilter struct {
+	language  string
+	stopwords map[string]bool
+	blocked   map[string]bool
+	stopped   int
+	dropped   int
+}
+
+func newTokenFilter(lang lingua.Language) *tokenFilter {
+	wl := getWordLists()
+	return &tokenFilter{
+		language:  strings.ToLower(lang.IsoCode639_1().String()),
+		stopwords: wl.words(model.WordListKindStopwords, lang),
+		blocked:   wl.words(model.WordListKindBlocklist, lang),
+	}
+}
+
+// keep reports whether a lowercase word is neither a stopword nor blocked
+func (f *tokenFilter) keep(word string) bool {
+	if f.stopwords[word] {
+		f.stopped++
+		return false
+	}
+	if f.blocked[word] {
+		f.dropped++
+		return false
+	}
+	return true
+}
+
+func (f *tokenFilter) flush() {
+	if f.stopped > 0 {
+		KeywordTokensFilteredTotal.WithLabelValues("stopword", f.language).Add(float64(f.stopped))
+	}
+	if f.dropped > 0 {
+		KeywordTokensFilteredTotal.WithLabelValues("blocklist", f.language).Add(float64(f.dropped))
+	}
+	f.stopped, f.dropped = 0, 0
+}